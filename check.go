@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// Nagios plugin exit codes.
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3
+)
+
+var checkStateNames = map[int]string{
+	checkOK:       "OK",
+	checkWarning:  "WARNING",
+	checkCritical: "CRITICAL",
+	checkUnknown:  "UNKNOWN",
+}
+
+// checkResult is the outcome of evaluating usage rows against thresholds.
+type checkResult struct {
+	state    int
+	summary  string
+	perfdata []string
+}
+
+// runCheck implements `aim check`: it evaluates every usage window against the
+// thresholds and exits using the Nagios plugin convention.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	warn := fs.Float64("warn", 70, "Usage percent at or above which a window is WARNING")
+	crit := fs.Float64("crit", 90, "Usage percent at or above which a window is CRITICAL")
+	showGeminiOld := fs.Bool("gemini-old", false, "Include Gemini 2.x models (gemini-2*) and other hidden models")
+	configPath := fs.String("config", "", "Path to config file (default ~/.config/aim/config.yaml)")
+	noHistory := fs.Bool("no-history", false, "Do not record this fetch in the local usage history")
+	providerList := fs.String("providers", "", "Comma-separated providers to check (default: all enabled in config)")
+	noRefresh := fs.Bool("no-refresh", false, "Never refresh tokens or rewrite credential files")
+	source := fs.String("source", "", "Credential source: auto, proxy, native, management or all (default from config, else auto)")
+	var authDirs stringsFlag
+	fs.Var(&authDirs, "auth-dir", "Directory of CLIProxyAPI credential files; repeat for several (default from config, else ~/.cli-proxy-api)")
+	proxyConfig := fs.String("proxy-config", "", "Path to a CLIProxyAPI config.yaml whose auth-dir holds credential files")
+	if err := fs.Parse(args); err != nil {
+		return checkUnknown
+	}
+	if *warn < 0 || *crit > 100 || *warn > *crit {
+		fmt.Fprintln(os.Stdout, "AIM UNKNOWN - thresholds must satisfy 0 <= --warn <= --crit <= 100")
+		return checkUnknown
+	}
+	selected, err := providers.ParseProviderList(*providerList)
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "AIM UNKNOWN - %v\n", err)
+		return checkUnknown
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "AIM UNKNOWN - %v\n", err)
+		return checkUnknown
+	}
+	if *noRefresh {
+		cfg.NoRefresh = true
+	}
+	if err := applySourceMode(cfg, *source); err != nil {
+		fmt.Fprintf(os.Stdout, "AIM UNKNOWN - %v\n", err)
+		return checkUnknown
+	}
+	if err := applyAuthDirs(cfg, authDirs, *proxyConfig); err != nil {
+		fmt.Fprintf(os.Stdout, "AIM UNKNOWN - %v\n", err)
+		return checkUnknown
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	rows := collectRows(ctx, newProviders(cfg, selected))
+	if !*noHistory {
+		recordHistory(rows, cfg.HistoryRetention())
+	}
+	rows = filterRows(rows, hiddenModels(cfg, *showGeminiOld))
+	sortRows(rows)
+
+	result := evaluateCheck(rows, *warn, *crit)
+	writeCheckResult(os.Stdout, result)
+	return result.state
+}
+
+// evaluateCheck classifies rows against the thresholds. Blocked windows are
+// always CRITICAL. The overall state is the most severe of CRITICAL, WARNING,
+// UNKNOWN (provider warnings), then OK.
+func evaluateCheck(rows []providers.UsageRow, warn, crit float64) checkResult {
+	var critical, warning, unknown []string
+	var perfdata []string
+	windows := 0
+
+	for _, row := range rows {
+		if row.IsGroup {
+			continue
+		}
+		if row.IsWarning {
+			unknown = append(unknown, strings.TrimSpace(row.Provider+" "+row.Label)+": "+row.WarningMsg)
+			continue
+		}
+		if !row.HasUsagePercent() {
+			continue
+		}
+
+		windows++
+		name := row.Provider + " " + row.Label
+		percent := int(math.Round(row.UsagePercent))
+		perfdata = append(perfdata, fmt.Sprintf("%s=%s%%;%s;%s;0;100",
+			perfLabel(name), formatPerfValue(row.UsagePercent), formatPerfValue(warn), formatPerfValue(crit)))
+
+		switch {
+		case row.Blocked:
+			critical = append(critical, fmt.Sprintf("%s %d%% blocked", name, percent))
+		case row.UsagePercent >= crit:
+			critical = append(critical, fmt.Sprintf("%s %d%%", name, percent))
+		case row.UsagePercent >= warn:
+			warning = append(warning, fmt.Sprintf("%s %d%%", name, percent))
+		}
+	}
+
+	result := checkResult{perfdata: perfdata}
+	switch {
+	case len(critical) > 0:
+		result.state = checkCritical
+		result.summary = strings.Join(append(critical, warning...), ", ")
+	case len(warning) > 0:
+		result.state = checkWarning
+		result.summary = strings.Join(warning, ", ")
+	case len(unknown) > 0:
+		result.state = checkUnknown
+		result.summary = strings.Join(unknown, "; ")
+	case windows == 0:
+		result.state = checkUnknown
+		result.summary = "no usage windows reported"
+	default:
+		result.state = checkOK
+		result.summary = fmt.Sprintf("%d windows below %s%%", windows, formatPerfValue(warn))
+	}
+
+	if len(unknown) > 0 && result.state != checkUnknown {
+		result.summary += fmt.Sprintf(" (%d provider warnings)", len(unknown))
+	}
+	return result
+}
+
+func writeCheckResult(w io.Writer, result checkResult) {
+	// A "|" in the summary would be read as the start of the perfdata.
+	summary := strings.ReplaceAll(result.summary, "|", "/")
+	line := fmt.Sprintf("AIM %s - %s", checkStateNames[result.state], summary)
+	if len(result.perfdata) > 0 {
+		line += " | " + strings.Join(result.perfdata, " ")
+	}
+	fmt.Fprintln(w, line)
+}
+
+// perfLabel quotes a perfdata label, doubling embedded single quotes as the
+// plugin guidelines require.
+func perfLabel(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+func formatPerfValue(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charlieyou/aim/internal/config"
+	"github.com/charlieyou/aim/internal/output"
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// loadConfig loads the config file at path, or from the default location when
+// path is empty. Only an explicitly requested file must exist.
+func loadConfig(path string) (*config.Config, error) {
+	required := path != ""
+	if path == "" {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			return config.Default(), nil
+		}
+		path = defaultPath
+	}
+	return config.Load(path, required)
+}
+
+// applyColorMode configures output coloring from a flag or config value.
+func applyColorMode(mode string) error {
+	parsed, err := output.ParseColorMode(mode)
+	if err != nil {
+		return err
+	}
+	output.SetColorMode(parsed)
+	return nil
+}
+
+// hiddenModels returns the label prefixes to hide, or nil when showAll is set.
+func hiddenModels(cfg *config.Config, showAll bool) []string {
+	if showAll {
+		return nil
+	}
+	return cfg.HiddenModels
+}
+
+// applySourceMode overrides every provider's credential source with a --source
+// flag value. An empty value keeps the config file's settings.
+func applySourceMode(cfg *config.Config, value string) error {
+	if value == "" {
+		return nil
+	}
+	mode, err := providers.ParseSourceMode(value)
+	if err != nil {
+		return err
+	}
+	if mode == providers.SourceModeManagement && cfg.ManagementURL == "" {
+		return fmt.Errorf("--source management requires management_url in the config file")
+	}
+	cfg.CredentialSource = string(mode)
+	for name, pc := range cfg.Providers {
+		pc.CredentialSource = ""
+		cfg.Providers[name] = pc
+	}
+	return nil
+}
+
+// stringsFlag collects the values of a repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// applyAuthDirs replaces the config file's proxy credential directories with
+// --auth-dir flag values and the auth-dir of a --proxy-config file. Per-provider
+// credentials_dir settings still take precedence. With neither flag set the
+// config file's settings are kept.
+func applyAuthDirs(cfg *config.Config, authDirs []string, proxyConfig string) error {
+	if len(authDirs) == 0 && proxyConfig == "" {
+		return nil
+	}
+	cfg.CredentialsDir = ""
+	cfg.AuthDirs = slices.Clone(authDirs)
+	if proxyConfig != "" {
+		dir, err := config.ProxyAuthDir(proxyConfig)
+		if err != nil {
+			return err
+		}
+		cfg.AuthDirs = append(cfg.AuthDirs, dir)
+	}
+	return nil
+}
+
+// credentialSourceName describes where credentials are read from for the
+// header line. When providers disagree, each is listed with its own sources,
+// e.g. "Claude: native CLI directories; Codex: ~/.cli-proxy-api/".
+func credentialSourceName(cfg *config.Config, list []namedProvider) string {
+	var names, descriptions []string
+	for _, np := range list {
+		reporter, ok := np.provider.(providers.SourceReporter)
+		if np.err != nil || !ok {
+			continue
+		}
+		names = append(names, np.name)
+		descriptions = append(descriptions, describeSources(cfg, np.name, reporter.CredentialSources()))
+	}
+	if len(descriptions) == 0 {
+		return ""
+	}
+	if !slices.ContainsFunc(descriptions, func(d string) bool { return d != descriptions[0] }) {
+		return descriptions[0]
+	}
+	parts := make([]string, len(names))
+	for i := range names {
+		parts[i] = names[i] + ": " + descriptions[i]
+	}
+	return strings.Join(parts, "; ")
+}
+
+// describeSources names the given sources, showing a configured proxy
+// directory or management URL instead of the generic name.
+func describeSources(cfg *config.Config, name string, sources []providers.CredentialSource) string {
+	parts := providers.DescribeSources(sources, cfg.ProviderOptions(name).CredentialsDirs, cfg.ManagementURL)
+	return strings.Join(parts, " + ")
+}
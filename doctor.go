@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/charlieyou/aim/internal/output"
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// runDoctor implements `aim doctor`: it lists every credential file the
+// providers consider, with what was parsed from it and why it is or is not
+// used. It exits 1 when a loaded file is broken or readable by other users.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file (default ~/.config/aim/config.yaml)")
+	providerList := fs.String("providers", "", "Comma-separated providers to inspect (default: all enabled in config)")
+	source := fs.String("source", "", "Credential source: auto, proxy, native, management or all (default from config, else auto)")
+	var authDirs stringsFlag
+	fs.Var(&authDirs, "auth-dir", "Directory of CLIProxyAPI credential files; repeat for several (default from config, else ~/.cli-proxy-api)")
+	proxyConfig := fs.String("proxy-config", "", "Path to a CLIProxyAPI config.yaml whose auth-dir holds credential files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	selected, err := providers.ParseProviderList(*providerList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim doctor: %v\n", err)
+		return 2
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim doctor: %v\n", err)
+		return 2
+	}
+	if err := applySourceMode(cfg, *source); err != nil {
+		fmt.Fprintf(os.Stderr, "aim doctor: %v\n", err)
+		return 2
+	}
+	if err := applyAuthDirs(cfg, authDirs, *proxyConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "aim doctor: %v\n", err)
+		return 2
+	}
+
+	var reports []providers.CredentialReport
+	for _, np := range newProviders(cfg, selected) {
+		if np.err != nil {
+			fmt.Fprintf(os.Stderr, "aim doctor: %s: %v\n", np.name, np.err)
+			continue
+		}
+		if diagnoser, ok := np.provider.(providers.Diagnoser); ok {
+			reports = append(reports, diagnoser.DiagnoseCredentials()...)
+		}
+	}
+
+	output.RenderDoctor(reports, os.Stdout)
+	if hasCredentialProblems(reports) {
+		return 1
+	}
+	return 0
+}
+
+// hasCredentialProblems reports whether any loaded credential file is broken
+// or exposes its secrets to other users.
+func hasCredentialProblems(reports []providers.CredentialReport) bool {
+	for _, r := range reports {
+		if r.ReadableByOthers() || (r.Loaded() && r.Err != "") {
+			return true
+		}
+	}
+	return false
+}
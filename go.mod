@@ -2,6 +2,9 @@ module github.com/charlieyou/aim
 
 go 1.24.0
 
-require golang.org/x/term v0.38.0
+require (
+	golang.org/x/term v0.38.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require golang.org/x/sys v0.39.0 // indirect
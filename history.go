@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charlieyou/aim/internal/history"
+	"github.com/charlieyou/aim/internal/output"
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// recordHistory appends successful usage rows to the local history store,
+// pruning records older than retention. Failures are reported on stderr but
+// never abort the caller.
+func recordHistory(rows []providers.UsageRow, retention time.Duration) {
+	path, err := history.DefaultPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim: failed to record history: %v\n", err)
+		return
+	}
+	records := history.RecordsFromRows(rows, time.Now())
+	if err := history.NewStoreWithRetention(path, retention).Append(records); err != nil {
+		fmt.Fprintf(os.Stderr, "aim: failed to record history: %v\n", err)
+	}
+}
+
+// loadForecastSamples returns recent history records to use as burn-rate
+// baselines. Failures are reported on stderr and yield no samples.
+func loadForecastSamples(now time.Time) []history.Record {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return nil
+	}
+	records, err := history.NewStore(path).Load(history.Filter{Since: now.Add(-history.ForecastLookback)})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim: failed to read history: %v\n", err)
+		return nil
+	}
+	return records
+}
+
+// applyForecasts annotates rows with burn-rate projections from earlier samples.
+func applyForecasts(rows []providers.UsageRow, samples []history.Record, now time.Time) {
+	if len(samples) == 0 {
+		return
+	}
+	for i := range rows {
+		rows[i].Forecast = history.Forecast(samples, rows[i], now)
+	}
+}
+
+// runHistory implements `aim history`: it prints the recorded usage samples
+// as one time series per provider account window.
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	provider := fs.String("provider", "", "Only show history for this provider (e.g. Codex)")
+	since := fs.String("since", "7d", "Only show samples newer than this (e.g. 7d, 12h)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	lookback, err := history.ParseSince(*since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim history: --since: %v\n", err)
+		return 2
+	}
+
+	path, err := history.DefaultPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim history: %v\n", err)
+		return 1
+	}
+
+	filter := history.Filter{Provider: *provider}
+	if lookback > 0 {
+		filter.Since = time.Now().Add(-lookback)
+	}
+
+	records, err := history.NewStore(path).Load(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim history: %v\n", err)
+		return 1
+	}
+
+	output.RenderHistory(history.GroupSeries(records), os.Stdout)
+	return 0
+}
@@ -0,0 +1,265 @@
+// Package config loads aim's optional YAML configuration file, which supplies
+// defaults for CLI flags, provider selection and credential locations.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charlieyou/aim/internal/providers"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the contents of config.yaml. Zero values mean "use the default".
+type Config struct {
+	Format               string                    `yaml:"format"`                 // "table" or "json"
+	Color                string                    `yaml:"color"`                  // "auto", "always" or "never"
+	Timeout              time.Duration             `yaml:"timeout"`                // overall fetch deadline
+	CredentialsDir       string                    `yaml:"credentials_dir"`        // CLIProxyAPI credential directory for all providers
+	AuthDirs             []string                  `yaml:"auth_dirs"`              // further credential directories searched after credentials_dir
+	ProxyConfig          string                    `yaml:"proxy_config"`           // CLIProxyAPI config.yaml whose auth-dir is searched too
+	CredentialSource     string                    `yaml:"credential_source"`      // "auto", "proxy", "native", "management" or "all"
+	ManagementURL        string                    `yaml:"management_url"`         // CLIProxyAPI base URL for the management API source
+	ManagementKey        string                    `yaml:"management_key"`         // management API key; $AIM_MANAGEMENT_KEY overrides
+	HiddenModels         []string                  `yaml:"hidden_models"`          // label prefixes hidden from output
+	RefreshSkew          time.Duration             `yaml:"refresh_skew"`           // refresh tokens this long before they expire
+	NoRefresh            bool                      `yaml:"no_refresh"`             // never refresh or rewrite credentials
+	HistoryRetentionDays int                       `yaml:"history_retention_days"` // days of usage history kept; 0 keeps all
+	Providers            map[string]ProviderConfig `yaml:"providers"`              // keyed by lower-case provider name
+}
+
+// ProviderConfig holds per-provider settings.
+type ProviderConfig struct {
+	Enabled          *bool         `yaml:"enabled"`
+	CredentialsDir   string        `yaml:"credentials_dir"`
+	CredentialSource string        `yaml:"credential_source"` // overrides the top-level credential_source
+	Timeout          time.Duration `yaml:"timeout"`
+}
+
+// Default returns the built-in configuration used when no file exists.
+func Default() *Config {
+	return &Config{
+		Format:               "table",
+		Color:                "auto",
+		Timeout:              60 * time.Second,
+		HiddenModels:         []string{"gemini-2"},
+		RefreshSkew:          providers.DefaultRefreshSkew,
+		HistoryRetentionDays: 90,
+	}
+}
+
+// DefaultPath returns $XDG_CONFIG_HOME/aim/config.yaml, falling back to
+// ~/.config/aim/config.yaml.
+func DefaultPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" || !filepath.IsAbs(configHome) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "aim", "config.yaml"), nil
+}
+
+// Load reads the config file at path on top of the defaults. A missing file
+// is not an error unless required is set.
+func Load(path string, required bool) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.normalize(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) normalize() error {
+	c.Format = strings.ToLower(strings.TrimSpace(c.Format))
+	if c.Format == "" {
+		c.Format = "table"
+	}
+	if c.Format != "table" && c.Format != "json" {
+		return fmt.Errorf("format must be table or json, got %q", c.Format)
+	}
+
+	c.Color = strings.ToLower(strings.TrimSpace(c.Color))
+	if c.Color == "" {
+		c.Color = "auto"
+	}
+	if c.Color != "auto" && c.Color != "always" && c.Color != "never" {
+		return fmt.Errorf("color must be auto, always or never, got %q", c.Color)
+	}
+
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = Default().Timeout
+	}
+
+	if c.RefreshSkew < 0 {
+		return fmt.Errorf("refresh_skew must not be negative")
+	}
+
+	if c.HistoryRetentionDays < 0 {
+		return fmt.Errorf("history_retention_days must not be negative")
+	}
+
+	if c.ProxyConfig != "" {
+		dir, err := ProxyAuthDir(expandHome(c.ProxyConfig))
+		if err != nil {
+			return fmt.Errorf("proxy_config: %w", err)
+		}
+		c.AuthDirs = append(c.AuthDirs, dir)
+	}
+
+	source, err := providers.ParseSourceMode(c.CredentialSource)
+	if err != nil {
+		return fmt.Errorf("credential_source: %w", err)
+	}
+	c.CredentialSource = string(source)
+
+	c.ManagementURL = strings.TrimSpace(c.ManagementURL)
+	if c.ManagementURL != "" {
+		u, err := url.Parse(c.ManagementURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("management_url must be an http or https URL, got %q", c.ManagementURL)
+		}
+	}
+	if source == providers.SourceModeManagement && c.ManagementURL == "" {
+		return fmt.Errorf("credential_source: management requires management_url")
+	}
+	if key := os.Getenv("AIM_MANAGEMENT_KEY"); key != "" {
+		c.ManagementKey = key
+	}
+
+	normalized := make(map[string]ProviderConfig, len(c.Providers))
+	for name, pc := range c.Providers {
+		if pc.Timeout < 0 {
+			return fmt.Errorf("providers.%s.timeout must not be negative", name)
+		}
+		if pc.CredentialSource != "" {
+			source, err := providers.ParseSourceMode(pc.CredentialSource)
+			if err != nil {
+				return fmt.Errorf("providers.%s.credential_source: %w", name, err)
+			}
+			pc.CredentialSource = string(source)
+		}
+		if pc.CredentialSource == string(providers.SourceModeManagement) && c.ManagementURL == "" {
+			return fmt.Errorf("providers.%s.credential_source: management requires management_url", name)
+		}
+		normalized[strings.ToLower(name)] = pc
+	}
+	c.Providers = normalized
+	return nil
+}
+
+// Provider returns the settings for the named provider (case-insensitive).
+func (c *Config) Provider(name string) ProviderConfig {
+	return c.Providers[strings.ToLower(name)]
+}
+
+// ProviderEnabled reports whether the named provider should be queried.
+// Providers are enabled unless explicitly disabled.
+func (c *Config) ProviderEnabled(name string) bool {
+	pc := c.Provider(name)
+	return pc.Enabled == nil || *pc.Enabled
+}
+
+// ProviderOptions returns the constructor options for the named provider.
+func (c *Config) ProviderOptions(name string) providers.Options {
+	pc := c.Provider(name)
+	dirs := c.CredentialDirs()
+	if pc.CredentialsDir != "" {
+		dirs = []string{pc.CredentialsDir}
+	}
+	for i, dir := range dirs {
+		dirs[i] = expandHome(dir)
+	}
+	source := pc.CredentialSource
+	if source == "" {
+		source = c.CredentialSource
+	}
+	return providers.Options{
+		CredentialsDirs: dirs,
+		Source:          providers.SourceMode(source),
+		ManagementURL:   c.ManagementURL,
+		ManagementKey:   c.ManagementKey,
+		Timeout:         pc.Timeout,
+		RefreshSkew:     c.RefreshSkew,
+		NoRefresh:       c.NoRefresh,
+	}
+}
+
+// HistoryRetention returns how long usage history is kept, or zero to keep
+// all of it.
+func (c *Config) HistoryRetention() time.Duration {
+	return time.Duration(c.HistoryRetentionDays) * 24 * time.Hour
+}
+
+// CredentialDirs returns the configured CLIProxyAPI credential directories
+// shared by all providers, or nil to use ~/.cli-proxy-api.
+func (c *Config) CredentialDirs() []string {
+	var dirs []string
+	if c.CredentialsDir != "" {
+		dirs = append(dirs, c.CredentialsDir)
+	}
+	return append(dirs, c.AuthDirs...)
+}
+
+// ProxyAuthDir returns the auth-dir setting of the CLIProxyAPI config file at
+// path. "~" is expanded and a relative directory is resolved against the
+// config file's directory.
+func ProxyAuthDir(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read CLIProxyAPI config %s: %w", path, err)
+	}
+	var proxyCfg struct {
+		AuthDir string `yaml:"auth-dir"`
+	}
+	if err := yaml.Unmarshal(data, &proxyCfg); err != nil {
+		return "", fmt.Errorf("failed to parse CLIProxyAPI config %s: %w", path, err)
+	}
+	dir := expandHome(strings.TrimSpace(proxyCfg.AuthDir))
+	if dir == "" {
+		return "", fmt.Errorf("CLIProxyAPI config %s does not set auth-dir", path)
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(path), dir)
+	}
+	return dir, nil
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
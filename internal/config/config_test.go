@@ -0,0 +1,248 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_MissingFileUsesDefaults(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), false)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Format != "table" || cfg.Color != "auto" || cfg.Timeout != 60*time.Second {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if len(cfg.HiddenModels) != 1 || cfg.HiddenModels[0] != "gemini-2" {
+		t.Errorf("unexpected default hidden models: %v", cfg.HiddenModels)
+	}
+	for _, name := range []string{"Claude", "Codex", "Gemini"} {
+		if !cfg.ProviderEnabled(name) {
+			t.Errorf("expected %s enabled by default", name)
+		}
+	}
+}
+
+func TestLoad_MissingRequiredFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), true); err == nil {
+		t.Fatal("expected error for missing required config file")
+	}
+}
+
+func TestLoad_FullConfig(t *testing.T) {
+	path := writeConfig(t, `
+format: json
+color: never
+timeout: 90s
+credentials_dir: /srv/proxy
+hidden_models: []
+refresh_skew: 2m
+no_refresh: true
+history_retention_days: 7
+credential_source: all
+providers:
+  Claude:
+    timeout: 10s
+  codex:
+    credentials_dir: /srv/codex
+    credential_source: Proxy
+  gemini:
+    enabled: false
+`)
+
+	cfg, err := Load(path, true)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Format != "json" || cfg.Color != "never" || cfg.Timeout != 90*time.Second {
+		t.Errorf("unexpected top-level settings: %+v", cfg)
+	}
+	if len(cfg.HiddenModels) != 0 {
+		t.Errorf("expected hidden models cleared, got %v", cfg.HiddenModels)
+	}
+	if cfg.HistoryRetention() != 7*24*time.Hour {
+		t.Errorf("HistoryRetention() = %v, want 7 days", cfg.HistoryRetention())
+	}
+	if cfg.ProviderEnabled("Gemini") {
+		t.Error("expected Gemini disabled")
+	}
+
+	claude := cfg.ProviderOptions("Claude")
+	if claude.Timeout != 10*time.Second || !slices.Equal(claude.CredentialsDirs, []string{"/srv/proxy"}) ||
+		claude.RefreshSkew != 2*time.Minute || !claude.NoRefresh || claude.Source != providers.SourceModeAll {
+		t.Errorf("unexpected Claude options: %+v", claude)
+	}
+	codex := cfg.ProviderOptions("Codex")
+	if !slices.Equal(codex.CredentialsDirs, []string{"/srv/codex"}) {
+		t.Errorf("expected per-provider credentials dir, got %q", codex.CredentialsDirs)
+	}
+	if codex.Source != providers.SourceModeProxy {
+		t.Errorf("expected per-provider credential source, got %q", codex.Source)
+	}
+}
+
+func TestLoad_ExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := writeConfig(t, "credentials_dir: ~/shared/proxy\n")
+
+	cfg, err := Load(path, true)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, want := cfg.ProviderOptions("Codex").CredentialsDirs, []string{filepath.Join(home, "shared", "proxy")}; !slices.Equal(got, want) {
+		t.Errorf("CredentialsDirs = %q, want %q", got, want)
+	}
+}
+
+func TestLoad_AuthDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	proxyDir := t.TempDir()
+	proxyConfig := filepath.Join(proxyDir, "config.yaml")
+	if err := os.WriteFile(proxyConfig, []byte("port: 8317\nauth-dir: ./auths\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := writeConfig(t, `
+credentials_dir: /srv/proxy
+auth_dirs:
+  - ~/team/proxy
+proxy_config: `+proxyConfig+`
+providers:
+  codex:
+    credentials_dir: /srv/codex
+`)
+
+	cfg, err := Load(path, true)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []string{"/srv/proxy", filepath.Join(home, "team", "proxy"), filepath.Join(proxyDir, "auths")}
+	if got := cfg.ProviderOptions("Claude").CredentialsDirs; !slices.Equal(got, want) {
+		t.Errorf("Claude CredentialsDirs = %q, want %q", got, want)
+	}
+	if got := cfg.ProviderOptions("Codex").CredentialsDirs; !slices.Equal(got, []string{"/srv/codex"}) {
+		t.Errorf("expected per-provider credentials dir to replace shared dirs, got %q", got)
+	}
+}
+
+func TestProxyAuthDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+		wantErr  string
+	}{
+		{"absolute", "auth-dir: /srv/auths\n", "/srv/auths", ""},
+		{"home", "auth-dir: \"~/.cli-proxy-api\"\n", filepath.Join(home, ".cli-proxy-api"), ""},
+		{"relative", "auth-dir: auths\n", filepath.Join(dir, "auths"), ""},
+		{"unset", "port: 8317\n", "", "does not set auth-dir"},
+		{"invalid", "auth-dir: [\n", "", "failed to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ProxyAuthDir(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ProxyAuthDir() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProxyAuthDir() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ProxyAuthDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ProxyAuthDir(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing CLIProxyAPI config")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{"bad format", "format: xml\n", "format"},
+		{"bad color", "color: sometimes\n", "color"},
+		{"unknown key", "formt: json\n", "formt"},
+		{"negative timeout", "timeout: -1s\n", "timeout"},
+		{"negative refresh skew", "refresh_skew: -1m\n", "refresh_skew"},
+		{"negative history retention", "history_retention_days: -1\n", "history_retention_days"},
+		{"bad credential source", "credential_source: both\n", "credential_source"},
+		{"bad provider credential source", "providers:\n  codex:\n    credential_source: both\n", "providers.codex.credential_source"},
+		{"bad management url", "management_url: proxy:8317\n", "management_url"},
+		{"management source without url", "credential_source: management\n", "management_url"},
+		{"missing proxy config", "proxy_config: /nonexistent/config.yaml\n", "proxy_config"},
+		{"provider management source without url", "providers:\n  codex:\n    credential_source: management\n", "management_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.contents), true)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoad_ManagementAPI(t *testing.T) {
+	t.Setenv("AIM_MANAGEMENT_KEY", "from-env")
+	path := writeConfig(t, `
+management_url: https://proxy.example.com:8317
+management_key: from-file
+credential_source: management
+`)
+
+	cfg, err := Load(path, true)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	opts := cfg.ProviderOptions("Codex")
+	if opts.ManagementURL != "https://proxy.example.com:8317" || opts.ManagementKey != "from-env" ||
+		opts.Source != providers.SourceModeManagement {
+		t.Errorf("unexpected management options: %+v", opts)
+	}
+}
+
+func TestDefaultPath_UsesXDGConfigHome(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "aim", "config.yaml"); path != want {
+		t.Errorf("DefaultPath() = %q, want %q", path, want)
+	}
+}
@@ -0,0 +1,26 @@
+// Package filelock provides advisory whole-file locks used to coordinate
+// concurrent aim processes (and other tools) touching the same files.
+package filelock
+
+import "os"
+
+// Lock acquires an exclusive advisory lock on f, blocking until it is available.
+func Lock(f *os.File) error {
+	return lock(f, true)
+}
+
+// RLock acquires a shared advisory lock on f, blocking until it is available.
+func RLock(f *os.File) error {
+	return lock(f, false)
+}
+
+// TryLock attempts to acquire an exclusive advisory lock on f without
+// blocking. It reports false if another holder has the lock.
+func TryLock(f *os.File) (bool, error) {
+	return tryLock(f)
+}
+
+// Unlock releases a lock previously acquired with Lock or RLock.
+func Unlock(f *os.File) error {
+	return unlock(f)
+}
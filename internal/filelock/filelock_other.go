@@ -0,0 +1,20 @@
+//go:build !unix
+
+package filelock
+
+import "os"
+
+// Advisory locking is not implemented on this platform; callers still get
+// atomic renames and O_APPEND writes, just without cross-process exclusion.
+
+func lock(f *os.File, exclusive bool) error {
+	return nil
+}
+
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,84 @@
+//go:build unix
+
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock_ExcludesOtherDescriptors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lockfile")
+
+	first, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	if err := Lock(first); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if err := Lock(second); err != nil {
+			t.Errorf("second Lock() error = %v", err)
+		}
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second lock acquired while first was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := Unlock(first); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	select {
+	case <-acquired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second lock not acquired after first was released")
+	}
+	_ = Unlock(second)
+}
+
+func TestTryLock_ReportsContention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lockfile")
+
+	first, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	if err := Lock(first); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	if ok, err := TryLock(second); err != nil || ok {
+		t.Fatalf("TryLock() while held = %v, %v; want false, nil", ok, err)
+	}
+
+	if err := Unlock(first); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if ok, err := TryLock(second); err != nil || !ok {
+		t.Fatalf("TryLock() after release = %v, %v; want true, nil", ok, err)
+	}
+	_ = Unlock(second)
+}
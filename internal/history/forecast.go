@@ -0,0 +1,80 @@
+package history
+
+import (
+	"time"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+const (
+	// ForecastLookback bounds how far back a baseline sample may be, so the
+	// projection reflects the recent pace rather than the whole window.
+	ForecastLookback = time.Hour
+	// forecastMinSpan is the minimum time between baseline and current sample;
+	// shorter spans are dominated by rounding in the reported percentages.
+	forecastMinSpan = 2 * time.Minute
+	// resetTolerance allows for jitter in reset timestamps reported by the
+	// APIs when deciding whether two samples belong to the same window period.
+	resetTolerance = 2 * time.Minute
+)
+
+// Forecast computes the burn rate for row from earlier samples of the same
+// window period and projects when it will reach 100%. It returns nil when no
+// usable baseline sample exists.
+func Forecast(samples []Record, row providers.UsageRow, now time.Time) *providers.Forecast {
+	if row.IsWarning || row.IsGroup || row.Label == "" {
+		return nil
+	}
+	name, account := providers.SplitProviderName(row.Provider)
+
+	var baseline *Record
+	for i := range samples {
+		rec := &samples[i]
+		if rec.Provider != name || rec.Account != account || rec.Window != row.Label {
+			continue
+		}
+		age := now.Sub(rec.Timestamp)
+		if age < forecastMinSpan || age > ForecastLookback {
+			continue
+		}
+		if !samePeriod(rec.ResetsAt, row.ResetTime) {
+			continue
+		}
+		if baseline == nil || rec.Timestamp.Before(baseline.Timestamp) {
+			baseline = rec
+		}
+	}
+	if baseline == nil || row.UsagePercent < baseline.UsedPercent {
+		// No baseline, or usage dropped, which means the window reset in between.
+		return nil
+	}
+
+	hours := now.Sub(baseline.Timestamp).Hours()
+	rate := (row.UsagePercent - baseline.UsedPercent) / hours
+	forecast := &providers.Forecast{RatePerHour: rate}
+	if rate <= 0 {
+		return forecast
+	}
+
+	remaining := 100 - row.UsagePercent
+	if remaining <= 0 {
+		forecast.ExhaustsAt = now
+		return forecast
+	}
+	exhaustsAt := now.Add(time.Duration(remaining / rate * float64(time.Hour)))
+	if row.ResetTime.IsZero() || exhaustsAt.Before(row.ResetTime) {
+		forecast.ExhaustsAt = exhaustsAt
+	}
+	return forecast
+}
+
+func samePeriod(recorded *time.Time, current time.Time) bool {
+	if recorded == nil || current.IsZero() {
+		return recorded == nil && current.IsZero()
+	}
+	diff := recorded.Sub(current)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= resetTolerance
+}
@@ -0,0 +1,88 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+func TestForecast_ProjectsExhaustionBeforeReset(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(2 * time.Hour)
+	samples := []Record{
+		{Timestamp: now.Add(-30 * time.Minute), Provider: "Claude", Account: "a", Window: "5-hour", UsedPercent: 40, ResetsAt: &reset},
+		{Timestamp: now.Add(-10 * time.Minute), Provider: "Claude", Account: "a", Window: "5-hour", UsedPercent: 55, ResetsAt: &reset},
+	}
+	row := providers.UsageRow{Provider: "Claude (a)", Label: "5-hour", UsagePercent: 60, ResetTime: reset}
+
+	forecast := Forecast(samples, row, now)
+	if forecast == nil {
+		t.Fatal("expected forecast, got nil")
+	}
+	// 20 points over 30 minutes = 40%/h; 40 points remaining = 1h.
+	if forecast.RatePerHour != 40 {
+		t.Errorf("RatePerHour = %v, want 40", forecast.RatePerHour)
+	}
+	if want := now.Add(time.Hour); !forecast.ExhaustsAt.Equal(want) {
+		t.Errorf("ExhaustsAt = %v, want %v", forecast.ExhaustsAt, want)
+	}
+}
+
+func TestForecast_NoExhaustionWhenResetComesFirst(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(30 * time.Minute)
+	samples := []Record{
+		{Timestamp: now.Add(-time.Hour), Provider: "Codex", Account: "a", Window: "5-hour", UsedPercent: 10, ResetsAt: &reset},
+	}
+	row := providers.UsageRow{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 20, ResetTime: reset}
+
+	forecast := Forecast(samples, row, now)
+	if forecast == nil {
+		t.Fatal("expected forecast, got nil")
+	}
+	if !forecast.ExhaustsAt.IsZero() {
+		t.Errorf("expected no exhaustion before reset, got %v", forecast.ExhaustsAt)
+	}
+}
+
+func TestForecast_NilWithoutUsableBaseline(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(2 * time.Hour)
+	oldReset := now.Add(-time.Hour)
+	row := providers.UsageRow{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 20, ResetTime: reset}
+
+	tests := []struct {
+		name    string
+		samples []Record
+	}{
+		{"no samples", nil},
+		{"too recent", []Record{{Timestamp: now.Add(-time.Minute), Provider: "Codex", Account: "a", Window: "5-hour", ResetsAt: &reset}}},
+		{"too old", []Record{{Timestamp: now.Add(-2 * time.Hour), Provider: "Codex", Account: "a", Window: "5-hour", ResetsAt: &reset}}},
+		{"previous period", []Record{{Timestamp: now.Add(-30 * time.Minute), Provider: "Codex", Account: "a", Window: "5-hour", ResetsAt: &oldReset}}},
+		{"other account", []Record{{Timestamp: now.Add(-30 * time.Minute), Provider: "Codex", Account: "b", Window: "5-hour", ResetsAt: &reset}}},
+		{"usage dropped", []Record{{Timestamp: now.Add(-30 * time.Minute), Provider: "Codex", Account: "a", Window: "5-hour", UsedPercent: 90, ResetsAt: &reset}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if forecast := Forecast(tt.samples, row, now); forecast != nil {
+				t.Errorf("expected nil forecast, got %+v", forecast)
+			}
+		})
+	}
+}
+
+func TestForecast_SteadyUsage(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(2 * time.Hour)
+	samples := []Record{
+		{Timestamp: now.Add(-20 * time.Minute), Provider: "Codex", Account: "a", Window: "5-hour", UsedPercent: 20, ResetsAt: &reset},
+	}
+	row := providers.UsageRow{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 20, ResetTime: reset}
+
+	forecast := Forecast(samples, row, now)
+	if forecast == nil || forecast.RatePerHour != 0 || !forecast.ExhaustsAt.IsZero() {
+		t.Errorf("expected steady forecast, got %+v", forecast)
+	}
+}
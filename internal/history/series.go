@@ -0,0 +1,68 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Series is the ordered sequence of samples for one provider account window.
+type Series struct {
+	Provider string
+	Account  string
+	Window   string
+	Points   []Record
+}
+
+// GroupSeries groups records by provider, account and window. Series are
+// ordered by provider, account, then window; points keep their input order.
+func GroupSeries(records []Record) []Series {
+	type seriesKey struct{ provider, account, window string }
+	index := make(map[seriesKey]int)
+	var series []Series
+
+	for _, rec := range records {
+		key := seriesKey{rec.Provider, rec.Account, rec.Window}
+		i, ok := index[key]
+		if !ok {
+			i = len(series)
+			index[key] = i
+			series = append(series, Series{Provider: rec.Provider, Account: rec.Account, Window: rec.Window})
+		}
+		series[i].Points = append(series[i].Points, rec)
+	}
+
+	sort.SliceStable(series, func(i, j int) bool {
+		if series[i].Provider != series[j].Provider {
+			return series[i].Provider < series[j].Provider
+		}
+		if series[i].Account != series[j].Account {
+			return series[i].Account < series[j].Account
+		}
+		return series[i].Window < series[j].Window
+	})
+	return series
+}
+
+// ParseSince parses a lookback such as "7d", "36h" or "90m".
+// A bare "d" suffix means days; anything else is passed to time.ParseDuration.
+func ParseSince(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,258 @@
+// Package history persists usage samples to an append-only local store so
+// that usage can be inspected over time.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charlieyou/aim/internal/filelock"
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+const historyFileName = "history.jsonl"
+
+// pruneSlack is how far past the retention period the oldest record may be
+// before Append rewrites the store, so the file is rewritten about once a day
+// rather than on every append.
+const pruneSlack = 24 * time.Hour
+
+// Record is a single usage sample for one window of one account.
+type Record struct {
+	Timestamp   time.Time  `json:"ts"`
+	Provider    string     `json:"provider"`
+	Account     string     `json:"account,omitempty"`
+	Window      string     `json:"window"`
+	UsedPercent float64    `json:"used_percent"`
+	ResetsAt    *time.Time `json:"resets_at,omitempty"`
+}
+
+// Filter restricts which records are returned by Load.
+type Filter struct {
+	Provider string    // case-insensitive provider name, e.g. "Codex"; empty matches all
+	Since    time.Time // only records at or after this time; zero matches all
+}
+
+func (f Filter) matches(rec Record) bool {
+	if f.Provider != "" && !strings.EqualFold(f.Provider, rec.Provider) {
+		return false
+	}
+	if !f.Since.IsZero() && rec.Timestamp.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Store is an append-only JSON Lines file of usage records.
+// Writes take an exclusive advisory lock and are issued as a single append,
+// so concurrent aim invocations never interleave partial records.
+type Store struct {
+	path      string
+	retention time.Duration // records older than this are pruned; zero keeps all
+}
+
+// NewStore returns a store backed by the file at path that keeps every record.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// NewStoreWithRetention returns a store backed by the file at path whose
+// Append drops records older than retention. A non-positive retention keeps
+// every record.
+func NewStoreWithRetention(path string, retention time.Duration) *Store {
+	return &Store{path: path, retention: retention}
+}
+
+// DefaultPath returns the history file location under the XDG data directory
+// ($XDG_DATA_HOME/aim, falling back to ~/.local/share/aim).
+func DefaultPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" || !filepath.IsAbs(dataHome) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "aim", historyFileName), nil
+}
+
+// Path returns the file backing the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append writes records to the end of the store, first pruning records older
+// than the store's retention period.
+func (s *Store) Append(records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := encoder.Encode(rec); err != nil {
+			return fmt.Errorf("failed to encode history record: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	if err := filelock.Lock(file); err != nil {
+		return fmt.Errorf("failed to lock history file %s: %w", s.path, err)
+	}
+	defer func() {
+		_ = filelock.Unlock(file)
+	}()
+
+	if err := s.pruneLocked(file, time.Now()); err != nil {
+		return err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write history file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// pruneLocked rewrites file without the records older than the retention
+// period. file must be open for reading and appending under an exclusive
+// lock. The file is rewritten in place rather than replaced, since the lock
+// belongs to it; the rewrite only happens once the oldest record is more than
+// pruneSlack past the cutoff. Lines that cannot be parsed are dropped with
+// the old records.
+func (s *Store) pruneLocked(file *os.File, now time.Time) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-s.retention)
+
+	// Records are appended in time order, so the first one is the oldest.
+	reader := bufio.NewReader(file)
+	var data []byte
+	stale := false
+	for {
+		line, err := reader.ReadBytes('\n')
+		data = append(data, line...)
+		var rec Record
+		if json.Unmarshal(line, &rec) == nil {
+			stale = rec.Timestamp.Before(cutoff.Add(-pruneSlack))
+			break
+		}
+		if err != nil {
+			break
+		}
+	}
+	if !stale {
+		return nil
+	}
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read history file %s: %w", s.path, err)
+	}
+	data = append(data, rest...)
+
+	var kept bytes.Buffer
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		var rec Record
+		if json.Unmarshal(line, &rec) == nil && !rec.Timestamp.Before(cutoff) {
+			kept.Write(line)
+		}
+	}
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to prune history file %s: %w", s.path, err)
+	}
+	if _, err := file.Write(kept.Bytes()); err != nil {
+		return fmt.Errorf("failed to prune history file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Load reads all records matching filter, ordered by timestamp.
+// A missing store yields no records. Lines that cannot be parsed (for example
+// a record truncated by a crash) are skipped.
+func (s *Store) Load(filter Filter) ([]Record, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history file %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	if err := filelock.RLock(file); err != nil {
+		return nil, fmt.Errorf("failed to lock history file %s: %w", s.path, err)
+	}
+	defer func() {
+		_ = filelock.Unlock(file)
+	}()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			continue
+		}
+		if filter.matches(rec) {
+			records = append(records, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file %s: %w", s.path, err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// RecordsFromRows converts successful usage rows into history records stamped at now.
+// Warning and group rows are skipped. Rows must be in their raw provider form
+// (before display grouping) so the account can be recovered.
+func RecordsFromRows(rows []providers.UsageRow, now time.Time) []Record {
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		if !row.HasUsagePercent() || row.Label == "" {
+			continue
+		}
+		name, account := providers.SplitProviderName(row.Provider)
+		rec := Record{
+			Timestamp:   now.UTC(),
+			Provider:    name,
+			Account:     account,
+			Window:      row.Label,
+			UsedPercent: row.UsagePercent,
+		}
+		if !row.ResetTime.IsZero() {
+			reset := row.ResetTime.UTC()
+			rec.ResetsAt = &reset
+		}
+		records = append(records, rec)
+	}
+	return records
+}
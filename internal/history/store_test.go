@@ -0,0 +1,255 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+func TestStore_AppendAndLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "aim", historyFileName))
+	base := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	reset := base.Add(5 * time.Hour)
+
+	if err := store.Append([]Record{
+		{Timestamp: base.Add(time.Hour), Provider: "Codex", Account: "a", Window: "5-hour", UsedPercent: 40, ResetsAt: &reset},
+		{Timestamp: base, Provider: "Claude", Account: "b", Window: "7-day", UsedPercent: 10},
+	}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	records, err := store.Load(Filter{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Provider != "Claude" || records[1].Provider != "Codex" {
+		t.Errorf("expected records ordered by timestamp, got %+v", records)
+	}
+	if records[1].ResetsAt == nil || !records[1].ResetsAt.Equal(reset) {
+		t.Errorf("expected reset time round-trip, got %v", records[1].ResetsAt)
+	}
+	if records[0].ResetsAt != nil {
+		t.Errorf("expected nil reset time, got %v", records[0].ResetsAt)
+	}
+
+	info, err := os.Stat(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected history file mode 0600, got %o", perm)
+	}
+}
+
+func TestStore_LoadFilters(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), historyFileName))
+	base := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	if err := store.Append([]Record{
+		{Timestamp: base, Provider: "Codex", Window: "5-hour"},
+		{Timestamp: base.Add(48 * time.Hour), Provider: "Codex", Window: "5-hour"},
+		{Timestamp: base.Add(48 * time.Hour), Provider: "Claude", Window: "5-hour"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := store.Load(Filter{Provider: "codex", Since: base.Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Provider != "Codex" || !records[0].Timestamp.Equal(base.Add(48*time.Hour)) {
+		t.Errorf("unexpected filtered records: %+v", records)
+	}
+}
+
+func TestStore_LoadMissingFile(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "missing.jsonl"))
+	records, err := store.Load(Filter{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("expected no records, got %d", len(records))
+	}
+}
+
+func TestStore_LoadSkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), historyFileName)
+	data := `{"ts":"2026-01-02T12:00:00Z","provider":"Codex","window":"5-hour","used_percent":5}
+{"ts":"2026-01-02T13:00:00Z","provid
+{"ts":"2026-01-02T14:00:00Z","provider":"Codex","window":"5-hour","used_percent":7}
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := NewStore(path).Load(Filter{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 valid records, got %d", len(records))
+	}
+}
+
+func TestStore_AppendPrunesOldRecords(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name      string
+		oldest    time.Time
+		wantCount int
+	}{
+		{"past retention and slack", now.Add(-40 * 24 * time.Hour), 2},
+		{"within slack", now.Add(-30*24*time.Hour - time.Hour), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStoreWithRetention(filepath.Join(t.TempDir(), historyFileName), 30*24*time.Hour)
+			if err := store.Append([]Record{
+				{Timestamp: tt.oldest, Provider: "Codex", Window: "5-hour"},
+				{Timestamp: now.Add(-24 * time.Hour), Provider: "Codex", Window: "5-hour"},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Append([]Record{{Timestamp: now, Provider: "Codex", Window: "5-hour"}}); err != nil {
+				t.Fatalf("Append() error = %v", err)
+			}
+
+			records, err := store.Load(Filter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != tt.wantCount {
+				t.Fatalf("expected %d records, got %+v", tt.wantCount, records)
+			}
+			if !records[len(records)-1].Timestamp.Equal(now) {
+				t.Errorf("expected the new record last, got %+v", records)
+			}
+		})
+	}
+}
+
+func TestStore_ConcurrentAppends(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), historyFileName))
+	const writers = 8
+	const perWriter = 25
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each writer opens its own descriptor, like separate aim processes.
+			s := NewStore(store.Path())
+			batch := make([]Record, perWriter)
+			for j := range batch {
+				batch[j] = Record{Timestamp: time.Now(), Provider: "Codex", Window: "5-hour", UsedPercent: float64(j)}
+			}
+			if err := s.Append(batch); err != nil {
+				t.Errorf("Append() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	records, err := store.Load(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != writers*perWriter {
+		t.Errorf("expected %d records, got %d", writers*perWriter, len(records))
+	}
+}
+
+func TestDefaultPath_UsesXDGDataHome(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(dataHome, "aim", historyFileName)
+	if path != want {
+		t.Errorf("DefaultPath() = %q, want %q", path, want)
+	}
+}
+
+func TestRecordsFromRows_SkipsWarningsAndSplitsAccount(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	rows := []providers.UsageRow{
+		{Provider: "Codex (a@example.com)", Label: "5-hour", UsagePercent: 12, ResetTime: now.Add(time.Hour)},
+		{Provider: "Claude (b)", IsWarning: true, WarningMsg: "boom"},
+		{Provider: "Gemini (c)", Label: "gemini-3.0-pro", UsagePercent: 50},
+	}
+
+	records := RecordsFromRows(rows, now)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Provider != "Codex" || records[0].Account != "a@example.com" || records[0].Window != "5-hour" {
+		t.Errorf("unexpected record: %+v", records[0])
+	}
+	if records[0].ResetsAt == nil {
+		t.Errorf("expected reset time on Codex record")
+	}
+	if records[1].ResetsAt != nil {
+		t.Errorf("expected nil reset time for zero ResetTime")
+	}
+}
+
+func TestGroupSeries(t *testing.T) {
+	base := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Timestamp: base, Provider: "Codex", Account: "a", Window: "7-day"},
+		{Timestamp: base, Provider: "Codex", Account: "a", Window: "5-hour"},
+		{Timestamp: base.Add(time.Hour), Provider: "Codex", Account: "a", Window: "5-hour"},
+		{Timestamp: base, Provider: "Claude", Account: "b", Window: "5-hour"},
+	}
+
+	series := GroupSeries(records)
+	if len(series) != 3 {
+		t.Fatalf("expected 3 series, got %d", len(series))
+	}
+	if series[0].Provider != "Claude" || series[1].Window != "5-hour" || series[2].Window != "7-day" {
+		t.Errorf("unexpected series order: %+v", series)
+	}
+	if len(series[1].Points) != 2 {
+		t.Errorf("expected 2 points for Codex 5-hour, got %d", len(series[1].Points))
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"", 0, false},
+		{"xd", 0, true},
+		{"-1h", 0, true},
+		{"week", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSince(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSince(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ColorMode controls whether ANSI colors are emitted.
+type ColorMode int32
+
+const (
+	// ColorAuto colors output only on terminals that allow it (the default).
+	ColorAuto ColorMode = iota
+	// ColorAlways forces colored output.
+	ColorAlways
+	// ColorNever disables colored output.
+	ColorNever
+)
+
+var colorMode atomic.Int32
+
+// SetColorMode sets the color mode for all subsequent rendering.
+func SetColorMode(mode ColorMode) {
+	colorMode.Store(int32(mode))
+}
+
+// ParseColorMode parses "auto", "always" or "never".
+func ParseColorMode(value string) (ColorMode, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "auto":
+		return ColorAuto, nil
+	case "always":
+		return ColorAlways, nil
+	case "never":
+		return ColorNever, nil
+	}
+	return ColorAuto, fmt.Errorf("unknown color mode %q (expected auto, always or never)", value)
+}
@@ -0,0 +1,109 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// RenderDoctor renders one block per provider listing each credential file it
+// considered, what was parsed from it and any problems found.
+func RenderDoctor(reports []providers.CredentialReport, w io.Writer) {
+	useColor := isColorEnabled(w)
+
+	if len(reports) == 0 {
+		fmt.Fprintln(w, "No credential files found.")
+		return
+	}
+
+	header := []string{"File", "Type", "Account", "Expires", "Refresh", "Mode", "Notes"}
+	rows := make([][]string, 0, len(reports))
+	for _, r := range reports {
+		refresh := "no"
+		if r.HasRefreshToken {
+			refresh = "yes"
+		}
+		rows = append(rows, []string{
+			displayPath(r.Path),
+			orDash(r.Type),
+			orDash(r.Account),
+			FormatResetTime(r.ExpiresAt),
+			refresh,
+			fmt.Sprintf("%04o", r.Mode),
+			strings.Join(doctorNotes(r, useColor), "; "),
+		})
+	}
+
+	widths := make([]int, len(header))
+	for i, title := range header {
+		widths[i] = stringWidth(title)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = maxInt(widths[i], visibleWidth(cell))
+		}
+	}
+
+	for i, r := range reports {
+		if i == 0 || r.Provider != reports[i-1].Provider {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, colorize(useColor, r.Provider, ansiBold))
+			fmt.Fprintln(w, colorize(useColor, "  "+formatDoctorRow(header, widths), ansiDim))
+		}
+		fmt.Fprintln(w, "  "+formatDoctorRow(rows[i], widths))
+	}
+}
+
+func formatDoctorRow(cells []string, widths []int) string {
+	padded := make([]string, len(cells))
+	for i, cell := range cells {
+		padded[i] = padRight(cell, widths[i])
+	}
+	return strings.TrimRight(strings.Join(padded, "  "), " ")
+}
+
+// doctorNotes lists the problems found with a credential file, most severe first.
+func doctorNotes(r providers.CredentialReport, useColor bool) []string {
+	var notes []string
+	if r.Err != "" {
+		notes = append(notes, colorize(useColor, sanitizeWarning(r.Err), ansiRed))
+	}
+	if r.ReadableByOthers() {
+		notes = append(notes, colorize(useColor, "readable by other users (chmod 600)", ansiYellow))
+	}
+	if !r.Loaded() {
+		notes = append(notes, colorize(useColor, "not loaded: "+r.Skipped, ansiDim))
+	}
+	if r.DuplicateOf != "" {
+		notes = append(notes, colorize(useColor, "skipped: duplicate of "+displayPath(r.DuplicateOf), ansiDim))
+	}
+	if len(notes) == 0 {
+		notes = append(notes, colorize(useColor, "ok", ansiGreen))
+	}
+	return notes
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// displayPath abbreviates paths under the home directory with "~".
+func displayPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+func TestRenderDoctor(t *testing.T) {
+	SetColorMode(ColorNever)
+	defer SetColorMode(ColorAuto)
+
+	reports := []providers.CredentialReport{
+		{Provider: "Codex", Path: "/srv/proxy/codex-a.json", Type: "codex", Account: "a@example.com", HasRefreshToken: true, Mode: 0600},
+		{Provider: "Codex", Path: "/srv/proxy/codex-b.json", Mode: 0644, Err: "failed to parse JSON"},
+		{Provider: "Codex", Path: "/srv/proxy/codex-c.json", Type: "codex", Account: "a@example.com", Mode: 0640, DuplicateOf: "/srv/proxy/codex-a.json"},
+		{Provider: "Gemini", Path: "/home/u/.gemini/oauth_creds.json", Type: "gemini", Mode: 0600, Skipped: "using ~/.cli-proxy-api/"},
+	}
+
+	var buf bytes.Buffer
+	RenderDoctor(reports, &buf)
+	out := buf.String()
+
+	want := []string{
+		"Codex\n",
+		"codex-a.json           codex   a@example.com",
+		"yes      0600  ok",
+		"0644  failed to parse JSON; readable by other users (chmod 600)",
+		"0640  skipped: duplicate of /srv/proxy/codex-a.json",
+		"\nGemini\n",
+		"not loaded: using ~/.cli-proxy-api/",
+	}
+	for _, s := range want {
+		if !strings.Contains(out, s) {
+			t.Errorf("output missing %q\n%s", s, out)
+		}
+	}
+	if strings.Count(out, "File") != 2 {
+		t.Errorf("expected one header per provider\n%s", out)
+	}
+}
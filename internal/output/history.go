@@ -0,0 +1,44 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/charlieyou/aim/internal/history"
+)
+
+// RenderHistory renders one block per provider account window, listing each
+// recorded sample with its usage bar and the reset time reported at the time.
+func RenderHistory(series []history.Series, w io.Writer) {
+	useColor := isColorEnabled(w)
+
+	if len(series) == 0 {
+		fmt.Fprintln(w, "No usage history recorded yet.")
+		return
+	}
+
+	for i, s := range series {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		provider := s.Provider
+		if s.Account != "" {
+			provider = fmt.Sprintf("%s (%s)", s.Provider, s.Account)
+		}
+		fmt.Fprintf(w, "%s  %s\n", formatProviderHeader(provider, useColor), s.Window)
+
+		for _, point := range s.Points {
+			usageStr := fmt.Sprintf("%s %3d%%", generateBar(defaultBarWidth, point.UsedPercent), int(math.Round(point.UsedPercent)))
+			usageStr = colorize(useColor, usageStr, usageColor(point.UsedPercent))
+			resetStr := "-"
+			if point.ResetsAt != nil {
+				resetStr = point.ResetsAt.Local().Format("Jan 2 15:04")
+			}
+			fmt.Fprintf(w, "  %s  %s  %s\n",
+				point.Timestamp.Local().Format("Jan 2 15:04"),
+				usageStr,
+				colorize(useColor, "resets "+resetStr, ansiDim))
+		}
+	}
+}
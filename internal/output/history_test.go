@@ -0,0 +1,59 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/charlieyou/aim/internal/history"
+)
+
+func TestRenderHistory(t *testing.T) {
+	SetColorMode(ColorNever)
+	defer SetColorMode(ColorAuto)
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.Local)
+	}
+	reset := at(3, 9, 0)
+	series := []history.Series{
+		{
+			Provider: "Claude",
+			Account:  "a@example.com",
+			Window:   "5h",
+			Points: []history.Record{
+				{Timestamp: at(2, 14, 5), UsedPercent: 12.4, ResetsAt: &reset},
+				{Timestamp: at(2, 15, 30), UsedPercent: 87.6, ResetsAt: &reset},
+			},
+		},
+		{
+			Provider: "Qwen",
+			Window:   "daily",
+			Points: []history.Record{
+				{Timestamp: at(2, 8, 0), UsedPercent: 100},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	RenderHistory(series, &buf)
+
+	want := "" +
+		"Claude (a@example.com)  5h\n" +
+		"  Mar 2 14:05  █░░░░░  12%  resets Mar 3 09:00\n" +
+		"  Mar 2 15:30  █████░  88%  resets Mar 3 09:00\n" +
+		"\n" +
+		"Qwen  daily\n" +
+		"  Mar 2 08:00  ██████ 100%  resets -\n"
+	if got := buf.String(); got != want {
+		t.Errorf("RenderHistory() output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderHistory_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	RenderHistory(nil, &buf)
+	if got := buf.String(); got != "No usage history recorded yet.\n" {
+		t.Errorf("output = %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// JSONSchemaVersion is bumped whenever a field is removed or changes meaning.
+// Adding new fields does not change the version.
+const JSONSchemaVersion = 1
+
+// jsonDocument is the top-level machine-readable output document.
+type jsonDocument struct {
+	Version          int       `json:"version"`
+	GeneratedAt      string    `json:"generated_at"`
+	CredentialSource string    `json:"credential_source,omitempty"`
+	Rows             []jsonRow `json:"rows"`
+}
+
+// jsonRow is a single usage window or warning in the JSON document.
+type jsonRow struct {
+	Provider       string       `json:"provider"`
+	Account        string       `json:"account"`
+	Plan           string       `json:"plan,omitempty"`
+	Source         string       `json:"source,omitempty"`
+	Window         string       `json:"window"`
+	WindowSeconds  *int64       `json:"window_seconds,omitempty"`
+	UsedPercent    *float64     `json:"used_percent"`
+	Blocked        bool         `json:"blocked"`
+	ResetsAt       *string      `json:"resets_at"`
+	Warning        bool         `json:"warning"`
+	WarningMessage string       `json:"warning_message,omitempty"`
+	Debug          string       `json:"debug,omitempty"`
+	BurnRate       *float64     `json:"burn_rate_per_hour,omitempty"`
+	ExhaustsAt     *string      `json:"projected_exhaustion,omitempty"`
+	Spend          *jsonSpend   `json:"spend,omitempty"`
+	Credits        *jsonCredits `json:"credits,omitempty"`
+	Quota          *jsonQuota   `json:"quota,omitempty"`
+}
+
+// jsonQuota reports a limit whose usage the provider does not report.
+type jsonQuota struct {
+	Limit int64  `json:"limit"`
+	Unit  string `json:"unit"`
+}
+
+// jsonCredits reports a prepaid credit balance.
+type jsonCredits struct {
+	HasCredits          bool     `json:"has_credits"`
+	Unlimited           bool     `json:"unlimited"`
+	Balance             *float64 `json:"balance"`
+	ApproxLocalMessages []int    `json:"approx_local_messages,omitempty"`
+	ApproxCloudMessages []int    `json:"approx_cloud_messages,omitempty"`
+}
+
+// jsonSpend reports pay-as-you-go spend in US dollars.
+type jsonSpend struct {
+	Enabled  bool     `json:"enabled"`
+	UsedUSD  *float64 `json:"used_usd"`
+	LimitUSD *float64 `json:"monthly_limit_usd"`
+}
+
+// RenderJSON writes usage rows as a versioned JSON document.
+// Rows are expected in their raw provider form (before display grouping),
+// so that the provider name and account can be reported separately.
+func RenderJSON(rows []providers.UsageRow, w io.Writer, source string) error {
+	return renderJSONAt(rows, w, source, time.Now())
+}
+
+func renderJSONAt(rows []providers.UsageRow, w io.Writer, source string, now time.Time) error {
+	doc := jsonDocument{
+		Version:          JSONSchemaVersion,
+		GeneratedAt:      now.UTC().Format(time.RFC3339),
+		CredentialSource: source,
+		Rows:             make([]jsonRow, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		if row.IsGroup {
+			continue
+		}
+		doc.Rows = append(doc.Rows, toJSONRow(row))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func toJSONRow(row providers.UsageRow) jsonRow {
+	name, account := splitProvider(row.Provider)
+	out := jsonRow{
+		Provider: name,
+		Account:  account,
+		Plan:     row.Plan,
+		Source:   row.Source,
+		Window:   row.Label,
+		Warning:  row.IsWarning,
+		Debug:    row.DebugInfo,
+	}
+
+	if row.Window > 0 {
+		seconds := int64(row.Window / time.Second)
+		out.WindowSeconds = &seconds
+	}
+
+	if row.IsWarning {
+		out.WarningMessage = row.WarningMsg
+		return out
+	}
+
+	if row.Spend != nil {
+		out.Spend = &jsonSpend{
+			Enabled:  row.Spend.Enabled,
+			UsedUSD:  row.Spend.Used,
+			LimitUSD: row.Spend.Limit,
+		}
+	}
+	if row.Credits != nil {
+		out.Credits = &jsonCredits{
+			HasCredits:          row.Credits.HasCredits,
+			Unlimited:           row.Credits.Unlimited,
+			Balance:             row.Credits.Balance,
+			ApproxLocalMessages: row.Credits.ApproxLocalMessages,
+			ApproxCloudMessages: row.Credits.ApproxCloudMessages,
+		}
+	}
+	if row.Quota != nil {
+		out.Quota = &jsonQuota{Limit: row.Quota.Limit, Unit: row.Quota.Unit}
+	}
+	out.Blocked = row.Blocked
+	if row.HasUsagePercent() {
+		used := row.UsagePercent
+		out.UsedPercent = &used
+	}
+	if !row.ResetTime.IsZero() {
+		reset := row.ResetTime.UTC().Format(time.RFC3339)
+		out.ResetsAt = &reset
+	}
+	if row.Forecast != nil {
+		rate := row.Forecast.RatePerHour
+		out.BurnRate = &rate
+		if !row.Forecast.ExhaustsAt.IsZero() {
+			exhausts := row.Forecast.ExhaustsAt.UTC().Format(time.RFC3339)
+			out.ExhaustsAt = &exhausts
+		}
+	}
+	return out
+}
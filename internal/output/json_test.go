@@ -0,0 +1,160 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+func TestRenderJSON_SplitsProviderAndAccount(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	rows := []providers.UsageRow{
+		{
+			Provider:     "Codex (user@example.com)",
+			Label:        "5-hour",
+			UsagePercent: 42.5,
+			ResetTime:    now.Add(2 * time.Hour),
+			DebugInfo:    "acct:abc123",
+			Source:       "proxy",
+		},
+		{
+			Provider:   "Claude",
+			IsWarning:  true,
+			WarningMsg: "No credential files found",
+		},
+		{
+			Provider: "Gemini (a)",
+			IsGroup:  true,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := renderJSONAt(rows, &buf, "native CLI directories", now); err != nil {
+		t.Fatalf("renderJSONAt() error = %v", err)
+	}
+
+	var doc struct {
+		Version          int    `json:"version"`
+		GeneratedAt      string `json:"generated_at"`
+		CredentialSource string `json:"credential_source"`
+		Rows             []struct {
+			Provider       string   `json:"provider"`
+			Account        string   `json:"account"`
+			Source         string   `json:"source"`
+			Window         string   `json:"window"`
+			UsedPercent    *float64 `json:"used_percent"`
+			ResetsAt       *string  `json:"resets_at"`
+			Warning        bool     `json:"warning"`
+			WarningMessage string   `json:"warning_message"`
+			Debug          string   `json:"debug"`
+		} `json:"rows"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, buf.String())
+	}
+
+	if doc.Version != JSONSchemaVersion {
+		t.Errorf("version = %d, want %d", doc.Version, JSONSchemaVersion)
+	}
+	if doc.GeneratedAt != "2026-01-02T12:00:00Z" {
+		t.Errorf("generated_at = %q", doc.GeneratedAt)
+	}
+	if doc.CredentialSource != "native CLI directories" {
+		t.Errorf("credential_source = %q", doc.CredentialSource)
+	}
+	if len(doc.Rows) != 2 {
+		t.Fatalf("expected 2 rows (group headers skipped), got %d", len(doc.Rows))
+	}
+
+	usage := doc.Rows[0]
+	if usage.Provider != "Codex" || usage.Account != "user@example.com" {
+		t.Errorf("expected Codex/user@example.com, got %q/%q", usage.Provider, usage.Account)
+	}
+	if usage.Window != "5-hour" {
+		t.Errorf("window = %q, want 5-hour", usage.Window)
+	}
+	if usage.UsedPercent == nil || *usage.UsedPercent != 42.5 {
+		t.Errorf("used_percent = %v, want 42.5", usage.UsedPercent)
+	}
+	if usage.ResetsAt == nil || *usage.ResetsAt != "2026-01-02T14:00:00Z" {
+		t.Errorf("resets_at = %v, want 2026-01-02T14:00:00Z", usage.ResetsAt)
+	}
+	if usage.Debug != "acct:abc123" {
+		t.Errorf("debug = %q", usage.Debug)
+	}
+	if usage.Source != "proxy" {
+		t.Errorf("source = %q, want proxy", usage.Source)
+	}
+
+	warning := doc.Rows[1]
+	if !warning.Warning || warning.WarningMessage != "No credential files found" {
+		t.Errorf("unexpected warning row: %+v", warning)
+	}
+	if warning.Provider != "Claude" || warning.Account != "" {
+		t.Errorf("expected bare Claude provider, got %q/%q", warning.Provider, warning.Account)
+	}
+	if warning.UsedPercent != nil || warning.ResetsAt != nil {
+		t.Errorf("expected null usage fields for warning row, got %+v", warning)
+	}
+}
+
+func TestRenderJSON_EmptyRows(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderJSON(nil, &buf, ""); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	rows, ok := doc["rows"].([]any)
+	if !ok || len(rows) != 0 {
+		t.Errorf("expected empty rows array, got %v", doc["rows"])
+	}
+	if _, ok := doc["credential_source"]; ok {
+		t.Errorf("expected credential_source omitted when empty")
+	}
+}
+
+func TestRenderJSON_Spend(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	used := 7.4
+	rows := []providers.UsageRow{{
+		Provider: "Claude (a)",
+		Label:    "extra usage",
+		Spend:    &providers.Spend{Enabled: true, Used: &used},
+	}}
+
+	var buf bytes.Buffer
+	if err := renderJSONAt(rows, &buf, "", now); err != nil {
+		t.Fatalf("renderJSONAt() error = %v", err)
+	}
+
+	var doc struct {
+		Rows []struct {
+			UsedPercent *float64 `json:"used_percent"`
+			Spend       *struct {
+				Enabled  bool     `json:"enabled"`
+				UsedUSD  *float64 `json:"used_usd"`
+				LimitUSD *float64 `json:"monthly_limit_usd"`
+			} `json:"spend"`
+		} `json:"rows"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, buf.String())
+	}
+	if len(doc.Rows) != 1 || doc.Rows[0].Spend == nil {
+		t.Fatalf("expected one row with spend, got %s", buf.String())
+	}
+	row := doc.Rows[0]
+	if row.UsedPercent != nil {
+		t.Errorf("used_percent = %v, want null without a limit", *row.UsedPercent)
+	}
+	if !row.Spend.Enabled || row.Spend.UsedUSD == nil || *row.Spend.UsedUSD != 7.4 || row.Spend.LimitUSD != nil {
+		t.Errorf("spend = %+v, want enabled with used 7.4 and no limit", *row.Spend)
+	}
+}
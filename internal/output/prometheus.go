@@ -0,0 +1,188 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// metricFamily describes a Prometheus gauge and its samples.
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+// RenderPrometheus writes usage rows in the Prometheus text exposition format.
+// Rows are expected in their raw provider form (before display grouping).
+//
+// Exposed gauges:
+//   - aim_usage_percent{provider,account,source,window}
+//   - aim_reset_timestamp_seconds{provider,account,source,window}
+//   - aim_blocked{provider,account,source,window}: 1 if requests are rejected until reset
+//   - aim_spend_dollars{provider,account,source,window}: pay-as-you-go spend this month
+//   - aim_spend_limit_dollars{provider,account,source,window}: monthly spend limit
+//   - aim_credits_balance{provider,account,source}: prepaid credit balance
+//   - aim_provider_up{provider,account,source}: 0 if any warning was reported, else 1
+//   - aim_account_plan_info{provider,account,source,plan}: always 1, for joining on plan
+//
+// The source label names the credential source (proxy, native or management)
+// so that series from different sources of the same account stay distinct.
+func RenderPrometheus(rows []providers.UsageRow, w io.Writer) error {
+	usage := metricFamily{
+		name: "aim_usage_percent",
+		help: "Percentage of the quota window that has been used (0-100).",
+	}
+	reset := metricFamily{
+		name: "aim_reset_timestamp_seconds",
+		help: "Unix timestamp at which the quota window resets.",
+	}
+	blocked := metricFamily{
+		name: "aim_blocked",
+		help: "Whether the provider rejects requests until the quota window resets.",
+	}
+	spend := metricFamily{
+		name: "aim_spend_dollars",
+		help: "Pay-as-you-go spend beyond the plan quota this month, in US dollars.",
+	}
+	spendLimit := metricFamily{
+		name: "aim_spend_limit_dollars",
+		help: "Monthly pay-as-you-go spend limit, in US dollars.",
+	}
+	credits := metricFamily{
+		name: "aim_credits_balance",
+		help: "Remaining prepaid credit balance for the provider account.",
+	}
+	up := metricFamily{
+		name: "aim_provider_up",
+		help: "Whether usage was fetched without warnings for the provider account.",
+	}
+
+	plan := metricFamily{
+		name: "aim_account_plan_info",
+		help: "Plan or tier of the provider account; the value is always 1.",
+	}
+
+	type accountKey struct{ provider, account, source string }
+	upState := make(map[accountKey]bool)
+	plans := make(map[accountKey]string)
+	var accountOrder []accountKey
+
+	for _, row := range rows {
+		if row.IsGroup {
+			continue
+		}
+		name, account := splitProvider(row.Provider)
+		key := accountKey{name, account, row.Source}
+		if _, seen := upState[key]; !seen {
+			upState[key] = true
+			accountOrder = append(accountOrder, key)
+		}
+		if row.Plan != "" {
+			plans[key] = row.Plan
+		}
+		if row.IsWarning {
+			upState[key] = false
+			continue
+		}
+
+		labels := [][2]string{{"provider", name}, {"account", account}, {"source", row.Source}, {"window", row.Label}}
+		if row.Credits != nil && row.Credits.Balance != nil {
+			credits.samples = append(credits.samples, metricSample{
+				labels: [][2]string{{"provider", name}, {"account", account}, {"source", row.Source}},
+				value:  *row.Credits.Balance,
+			})
+		}
+		if row.Spend != nil {
+			if row.Spend.Used != nil {
+				spend.samples = append(spend.samples, metricSample{labels: labels, value: *row.Spend.Used})
+			}
+			if row.Spend.Limit != nil {
+				spendLimit.samples = append(spendLimit.samples, metricSample{labels: labels, value: *row.Spend.Limit})
+			}
+		}
+		if row.HasUsagePercent() {
+			usage.samples = append(usage.samples, metricSample{labels: labels, value: row.UsagePercent})
+			value := 0.0
+			if row.Blocked {
+				value = 1
+			}
+			blocked.samples = append(blocked.samples, metricSample{labels: labels, value: value})
+		}
+		if !row.ResetTime.IsZero() {
+			reset.samples = append(reset.samples, metricSample{labels: labels, value: float64(row.ResetTime.Unix())})
+		}
+	}
+
+	sort.SliceStable(accountOrder, func(i, j int) bool {
+		if accountOrder[i].provider != accountOrder[j].provider {
+			return accountOrder[i].provider < accountOrder[j].provider
+		}
+		if accountOrder[i].account != accountOrder[j].account {
+			return accountOrder[i].account < accountOrder[j].account
+		}
+		return accountOrder[i].source < accountOrder[j].source
+	})
+	for _, key := range accountOrder {
+		value := 0.0
+		if upState[key] {
+			value = 1
+		}
+		up.samples = append(up.samples, metricSample{
+			labels: [][2]string{{"provider", key.provider}, {"account", key.account}, {"source", key.source}},
+			value:  value,
+		})
+		if p, ok := plans[key]; ok {
+			plan.samples = append(plan.samples, metricSample{
+				labels: [][2]string{{"provider", key.provider}, {"account", key.account}, {"source", key.source}, {"plan", p}},
+				value:  1,
+			})
+		}
+	}
+
+	for _, family := range []metricFamily{usage, reset, blocked, spend, spendLimit, credits, up, plan} {
+		if err := writeMetricFamily(w, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMetricFamily(w io.Writer, family metricFamily) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", family.name, family.help, family.name); err != nil {
+		return err
+	}
+	for _, sample := range family.samples {
+		value := strconv.FormatFloat(sample.value, 'f', -1, 64)
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", family.name, formatMetricLabels(sample.labels), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatMetricLabels(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", label[0], escapeMetricLabel(label[1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeMetricLabel(value string) string {
+	return metricLabelEscaper.Replace(value)
+}
@@ -0,0 +1,79 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+func TestRenderPrometheus_Gauges(t *testing.T) {
+	reset := time.Unix(1767385852, 0)
+	rows := []providers.UsageRow{
+		{Provider: "Codex (a@example.com)", Label: "5-hour", Plan: "pro", Source: "proxy", UsagePercent: 81.5, ResetTime: reset},
+		{Provider: "Codex (a@example.com)", Label: "5-hour", Source: "native", UsagePercent: 40, ResetTime: reset},
+		{Provider: "Claude (b@example.com)", IsWarning: true, WarningMsg: "authentication failed", Source: "native"},
+		{Provider: "Gemini (c)", IsGroup: true},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderPrometheus(rows, &buf); err != nil {
+		t.Fatalf("RenderPrometheus() error = %v", err)
+	}
+	out := buf.String()
+
+	want := []string{
+		"# TYPE aim_usage_percent gauge",
+		`aim_usage_percent{provider="Codex",account="a@example.com",source="proxy",window="5-hour"} 81.5`,
+		`aim_usage_percent{provider="Codex",account="a@example.com",source="native",window="5-hour"} 40`,
+		`aim_reset_timestamp_seconds{provider="Codex",account="a@example.com",source="proxy",window="5-hour"} 1767385852`,
+		`aim_provider_up{provider="Claude",account="b@example.com",source="native"} 0`,
+		`aim_provider_up{provider="Codex",account="a@example.com",source="native"} 1`,
+		`aim_provider_up{provider="Codex",account="a@example.com",source="proxy"} 1`,
+		`aim_account_plan_info{provider="Codex",account="a@example.com",source="proxy",plan="pro"} 1`,
+	}
+	for _, line := range want {
+		if !strings.Contains(out, line) {
+			t.Errorf("output missing %q\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, `account="c"`) {
+		t.Errorf("group header rows should not produce samples\n%s", out)
+	}
+}
+
+func TestRenderPrometheus_SameEmailInTwoProjects(t *testing.T) {
+	rows := []providers.UsageRow{
+		{Provider: "Gemini (a@example.com/proj-1)", Label: "gemini-2.5-pro", Source: "proxy", UsagePercent: 10},
+		{Provider: "Gemini (a@example.com/proj-2)", Label: "gemini-2.5-pro", Source: "proxy", UsagePercent: 20},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderPrometheus(rows, &buf); err != nil {
+		t.Fatalf("RenderPrometheus() error = %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		series, _, _ := strings.Cut(line, "} ")
+		if seen[series] {
+			t.Errorf("duplicate series %s}\n%s", series, buf.String())
+		}
+		seen[series] = true
+	}
+	if !strings.Contains(buf.String(), `account="a@example.com/proj-2"`) {
+		t.Errorf("expected the project in the account label\n%s", buf.String())
+	}
+}
+
+func TestEscapeMetricLabel(t *testing.T) {
+	got := escapeMetricLabel("a\"b\\c\nd")
+	want := `a\"b\\c\nd`
+	if got != want {
+		t.Errorf("escapeMetricLabel() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// RenderRefreshResults renders one line per account with the outcome of a
+// credential refresh run and the token expiry afterwards.
+func RenderRefreshResults(results []providers.RefreshResult, w io.Writer) {
+	useColor := isColorEnabled(w)
+
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No credentials found.")
+		return
+	}
+
+	accountWidth := stringWidth("Account")
+	statusWidth := stringWidth("Status")
+	expiresWidth := stringWidth("Expires")
+	for _, result := range results {
+		accountWidth = maxInt(accountWidth, stringWidth(result.Provider))
+		statusWidth = maxInt(statusWidth, stringWidth(string(result.Status)))
+		expiresWidth = maxInt(expiresWidth, stringWidth(FormatResetTime(result.ExpiresAt)))
+	}
+
+	header := strings.Join([]string{
+		padRight("Account", accountWidth),
+		padRight("Status", statusWidth),
+		padRight("Expires", expiresWidth),
+		"Detail",
+	}, "  ")
+	fmt.Fprintln(w, colorize(useColor, header, ansiBold))
+
+	for _, result := range results {
+		status := padRight(string(result.Status), statusWidth)
+		status = colorize(useColor, status, refreshStatusColor(result.Status))
+		line := strings.Join([]string{
+			padRight(formatProviderHeader(result.Provider, useColor), accountWidth),
+			status,
+			padRight(FormatResetTime(result.ExpiresAt), expiresWidth),
+			colorize(useColor, sanitizeWarning(result.Message), ansiDim),
+		}, "  ")
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+func refreshStatusColor(status providers.RefreshStatus) string {
+	switch status {
+	case providers.RefreshRefreshed, providers.RefreshReused, providers.RefreshValid:
+		return ansiGreen
+	case providers.RefreshFailed:
+		return strings.Join([]string{ansiRed, ansiBold}, "")
+	}
+	return ansiDim
+}
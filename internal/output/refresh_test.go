@@ -0,0 +1,43 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+func TestRenderRefreshResults(t *testing.T) {
+	SetColorMode(ColorNever)
+	defer SetColorMode(ColorAuto)
+
+	results := []providers.RefreshResult{
+		{Provider: "Claude (a@example.com)", Status: providers.RefreshValid},
+		{Provider: "Codex (b@example.com)", Status: providers.RefreshFailed, Message: "API returned status 400: invalid_grant"},
+	}
+
+	var buf bytes.Buffer
+	RenderRefreshResults(results, &buf)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d lines:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "Account") || !strings.Contains(lines[0], "Detail") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "Claude (a@example.com)  valid") {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "failed") || !strings.HasSuffix(lines[2], "invalid_grant") {
+		t.Errorf("unexpected row %q", lines[2])
+	}
+}
+
+func TestRenderRefreshResults_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	RenderRefreshResults(nil, &buf)
+	if got := buf.String(); got != "No credentials found.\n" {
+		t.Errorf("output = %q", got)
+	}
+}
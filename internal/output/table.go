@@ -5,6 +5,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -88,6 +89,12 @@ func maxInt(a, b int) int {
 }
 
 func isColorEnabled(w io.Writer) bool {
+	switch ColorMode(colorMode.Load()) {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
 	if os.Getenv("NO_COLOR") != "" {
 		return false
 	}
@@ -166,16 +173,7 @@ func padRight(value string, width int) string {
 }
 
 func splitProvider(provider string) (string, string) {
-	start := strings.Index(provider, " (")
-	if start == -1 || !strings.HasSuffix(provider, ")") {
-		return provider, ""
-	}
-	name := strings.TrimSpace(provider[:start])
-	detail := strings.TrimSuffix(provider[start+2:], ")")
-	if name == "" || detail == "" {
-		return provider, ""
-	}
-	return name, detail
+	return providers.SplitProviderName(provider)
 }
 
 func formatProviderHeader(provider string, useColor bool) string {
@@ -204,15 +202,171 @@ func terminalWidth(w io.Writer) (int, bool) {
 	return width, true
 }
 
+// usageSuffix is the text shown after the usage bar: the rounded percentage,
+// followed by the spend for pay-as-you-go rows and a marker for blocked windows.
+func usageSuffix(row providers.UsageRow) string {
+	text := fmt.Sprintf("%d%%", int(math.Round(row.UsagePercent)))
+	if row.Spend != nil {
+		text += " " + formatSpend(row.Spend)
+	}
+	if row.Blocked {
+		text += " BLOCKED"
+	}
+	return text
+}
+
+// formatSpend describes pay-as-you-go spend, e.g. "$12.50 / $50.00".
+func formatSpend(spend *providers.Spend) string {
+	if spend == nil {
+		return ""
+	}
+	if !spend.Enabled {
+		return "disabled"
+	}
+	used := "$0.00"
+	if spend.Used != nil {
+		used = fmt.Sprintf("$%.2f", *spend.Used)
+	}
+	if spend.Limit == nil {
+		return used + " (no limit)"
+	}
+	return fmt.Sprintf("%s / $%.2f", used, *spend.Limit)
+}
+
+// formatQuota describes a limit with unreported usage, e.g. "? / 2000 requests".
+func formatQuota(quota *providers.Quota) string {
+	return strings.TrimSpace(fmt.Sprintf("? / %d %s", quota.Limit, quota.Unit))
+}
+
+// formatCredits describes a credit balance, e.g. "balance 12.5 (~10-20 local, ~2-4 cloud msgs)".
+func formatCredits(credits *providers.Credits) string {
+	if credits == nil {
+		return ""
+	}
+	if credits.Unlimited {
+		return "unlimited"
+	}
+	if !credits.HasCredits {
+		return "none"
+	}
+	text := "balance ?"
+	if credits.Balance != nil {
+		text = "balance " + strconv.FormatFloat(*credits.Balance, 'f', -1, 64)
+	}
+	var approx []string
+	if r := formatApproxRange(credits.ApproxLocalMessages); r != "" {
+		approx = append(approx, r+" local")
+	}
+	if r := formatApproxRange(credits.ApproxCloudMessages); r != "" {
+		approx = append(approx, r+" cloud")
+	}
+	if len(approx) > 0 {
+		text += " (" + strings.Join(approx, ", ") + " msgs)"
+	}
+	return text
+}
+
+func formatApproxRange(values []int) string {
+	switch {
+	case len(values) == 0:
+		return ""
+	case len(values) == 1 || values[0] == values[len(values)-1]:
+		return fmt.Sprintf("~%d", values[0])
+	}
+	return fmt.Sprintf("~%d-%d", values[0], values[len(values)-1])
+}
+
+// formatUsageDetail describes rows that have no usage percentage.
+func formatUsageDetail(row providers.UsageRow) string {
+	if row.Credits != nil {
+		return formatCredits(row.Credits)
+	}
+	if row.Quota != nil {
+		return formatQuota(row.Quota)
+	}
+	return formatSpend(row.Spend)
+}
+
+// hasPlans reports whether any row carries an account plan, in which case
+// the Plan column is shown.
+func hasPlans(rows []providers.UsageRow) bool {
+	for _, row := range rows {
+		if row.Plan != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSources reports whether rows come from more than one credential source,
+// in which case the Source column is shown.
+func hasSources(rows []providers.UsageRow) bool {
+	first := ""
+	for _, row := range rows {
+		if row.Source == "" {
+			continue
+		}
+		if first == "" {
+			first = row.Source
+		} else if row.Source != first {
+			return true
+		}
+	}
+	return false
+}
+
+// hasForecasts reports whether any row carries a burn-rate projection,
+// in which case the Projected column is shown.
+func hasForecasts(rows []providers.UsageRow) bool {
+	for _, row := range rows {
+		if row.Forecast != nil && !row.IsWarning && !row.IsGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// formatForecastFrom describes a row's projection relative to now.
+func formatForecastFrom(row providers.UsageRow, now time.Time) string {
+	if row.Forecast == nil {
+		return "-"
+	}
+	if row.Forecast.RatePerHour <= 0 {
+		return "steady"
+	}
+	if row.Forecast.ExhaustsAt.IsZero() {
+		return "ok until reset"
+	}
+	if !row.Forecast.ExhaustsAt.After(now) {
+		return "exhausted"
+	}
+	return "100% " + formatResetTimeFrom(row.Forecast.ExhaustsAt, now)
+}
+
 func computeBarWidth(rows []providers.UsageRow, debug bool, termWidth int, now time.Time) int {
 	if termWidth <= 0 {
 		return defaultBarWidth
 	}
 
+	projected := hasForecasts(rows)
 	providerWidth := stringWidth("Provider")
 	windowWidth := stringWidth("Window")
 	usageHeaderWidth := stringWidth("Usage")
 	resetWidth := stringWidth("Resets At")
+	projectedWidth := 0
+	if projected {
+		projectedWidth = stringWidth("Projected")
+	}
+	planned := hasPlans(rows)
+	planWidth := 0
+	if planned {
+		planWidth = stringWidth("Plan")
+	}
+	sourced := hasSources(rows)
+	sourceWidth := 0
+	if sourced {
+		sourceWidth = stringWidth("Source")
+	}
 	debugWidth := 0
 	if debug {
 		debugWidth = stringWidth("Debug")
@@ -223,6 +377,12 @@ func computeBarWidth(rows []providers.UsageRow, debug bool, termWidth int, now t
 
 	for _, row := range rows {
 		providerWidth = maxInt(providerWidth, stringWidth(row.Provider))
+		if planned {
+			planWidth = maxInt(planWidth, stringWidth(row.Plan))
+		}
+		if sourced {
+			sourceWidth = maxInt(sourceWidth, stringWidth(row.Source))
+		}
 
 		if row.IsGroup {
 			continue
@@ -240,12 +400,17 @@ func computeBarWidth(rows []providers.UsageRow, debug bool, termWidth int, now t
 		windowWidth = maxInt(windowWidth, stringWidth(row.Label))
 		resetStr := formatResetTimeFrom(row.ResetTime, now)
 		resetWidth = maxInt(resetWidth, stringWidth(resetStr))
+		if projected {
+			projectedWidth = maxInt(projectedWidth, stringWidth(formatForecastFrom(row, now)))
+		}
 		if debug {
 			debugWidth = maxInt(debugWidth, stringWidth(row.DebugInfo))
 		}
 
-		percentStr := fmt.Sprintf("%d%%", int(math.Round(row.UsagePercent)))
-		percentWidth = maxInt(percentWidth, stringWidth(percentStr))
+		if !row.HasUsagePercent() {
+			continue
+		}
+		percentWidth = maxInt(percentWidth, stringWidth(usageSuffix(row)))
 		hasUsage = true
 	}
 
@@ -254,12 +419,21 @@ func computeBarWidth(rows []providers.UsageRow, debug bool, termWidth int, now t
 	}
 
 	columns := 4
+	if planned {
+		columns++
+	}
+	if sourced {
+		columns++
+	}
+	if projected {
+		columns++
+	}
 	if debug {
 		columns++
 	}
 	gapWidth := 2
 
-	fixedContent := providerWidth + windowWidth + resetWidth
+	fixedContent := providerWidth + planWidth + sourceWidth + windowWidth + resetWidth + projectedWidth
 	if debug {
 		fixedContent += debugWidth
 	}
@@ -299,8 +473,21 @@ func RenderTable(rows []providers.UsageRow, w io.Writer, debug bool) {
 		barWidth = computeBarWidth(rows, debug, termWidth, now)
 	}
 	useColor := isColorEnabled(w)
+	projected := hasForecasts(rows)
 
-	headers := []string{"Provider", "Window", "Usage", "Resets At"}
+	planned := hasPlans(rows)
+	headers := []string{"Provider"}
+	if planned {
+		headers = append(headers, "Plan")
+	}
+	sourced := hasSources(rows)
+	if sourced {
+		headers = append(headers, "Source")
+	}
+	headers = append(headers, "Window", "Usage", "Resets At")
+	if projected {
+		headers = append(headers, "Projected")
+	}
 	if debug {
 		headers = append(headers, "Debug")
 	}
@@ -319,7 +506,17 @@ func RenderTable(rows []providers.UsageRow, w io.Writer, debug bool) {
 
 		if row.IsGroup {
 			provider := formatProviderHeader(row.Provider, useColor)
-			cells = append(cells, provider, "", "", "")
+			cells = append(cells, provider)
+			if planned {
+				cells = append(cells, colorize(useColor, row.Plan, ansiDim))
+			}
+			if sourced {
+				cells = append(cells, colorize(useColor, row.Source, ansiDim))
+			}
+			cells = append(cells, "", "", "")
+			if projected {
+				cells = append(cells, "")
+			}
 			if debug {
 				cells = append(cells, "")
 			}
@@ -335,7 +532,17 @@ func RenderTable(rows []providers.UsageRow, w io.Writer, debug bool) {
 			if strings.HasPrefix(provider, "  ") {
 				provider = colorize(useColor, provider, ansiDim)
 			}
-			cells = append(cells, provider, warnText, "", "")
+			cells = append(cells, provider)
+			if planned {
+				cells = append(cells, row.Plan)
+			}
+			if sourced {
+				cells = append(cells, row.Source)
+			}
+			cells = append(cells, warnText, "", "")
+			if projected {
+				cells = append(cells, "")
+			}
 			if debug {
 				cells = append(cells, row.DebugInfo)
 			}
@@ -343,8 +550,17 @@ func RenderTable(rows []providers.UsageRow, w io.Writer, debug bool) {
 			continue
 		}
 
-		usageStr := fmt.Sprintf("%s %d%%", generateBar(barWidth, row.UsagePercent), int(math.Round(row.UsagePercent)))
-		usageStr = colorize(useColor, usageStr, usageColor(row.UsagePercent))
+		var usageStr string
+		if row.HasUsagePercent() {
+			usageStr = generateBar(barWidth, row.UsagePercent) + " " + usageSuffix(row)
+			if row.Blocked {
+				usageStr = colorize(useColor, usageStr, ansiRed, ansiBold)
+			} else {
+				usageStr = colorize(useColor, usageStr, usageColor(row.UsagePercent))
+			}
+		} else {
+			usageStr = colorize(useColor, formatUsageDetail(row), ansiDim)
+		}
 		resetStr := formatResetTimeFrom(row.ResetTime, now)
 		if !row.ResetTime.IsZero() {
 			diff := row.ResetTime.Sub(now)
@@ -358,7 +574,23 @@ func RenderTable(rows []providers.UsageRow, w io.Writer, debug bool) {
 		if strings.HasPrefix(provider, "  ") {
 			provider = colorize(useColor, provider, ansiDim)
 		}
-		cells = append(cells, provider, row.Label, usageStr, resetStr)
+		cells = append(cells, provider)
+		if planned {
+			cells = append(cells, row.Plan)
+		}
+		if sourced {
+			cells = append(cells, row.Source)
+		}
+		cells = append(cells, row.Label, usageStr, resetStr)
+		if projected {
+			forecastStr := formatForecastFrom(row, now)
+			if row.Forecast != nil && !row.Forecast.ExhaustsAt.IsZero() {
+				forecastStr = colorize(useColor, forecastStr, ansiRed, ansiBold)
+			} else {
+				forecastStr = colorize(useColor, forecastStr, ansiDim)
+			}
+			cells = append(cells, forecastStr)
+		}
 		if debug {
 			cells = append(cells, row.DebugInfo)
 		}
@@ -385,3 +385,201 @@ func TestTruncateRunes(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderTable_ProjectedColumn(t *testing.T) {
+	now := time.Now()
+	rows := []providers.UsageRow{
+		{
+			Provider:     "Claude",
+			Label:        "5-hour",
+			UsagePercent: 60,
+			ResetTime:    now.Add(2 * time.Hour),
+			Forecast:     &providers.Forecast{RatePerHour: 40, ExhaustsAt: now.Add(40*time.Minute + 30*time.Second)},
+		},
+		{
+			Provider:     "Claude",
+			Label:        "7-day",
+			UsagePercent: 10,
+			ResetTime:    now.Add(48 * time.Hour),
+			Forecast:     &providers.Forecast{RatePerHour: 0.5},
+		},
+		{
+			Provider:     "Codex",
+			Label:        "5-hour",
+			UsagePercent: 10,
+			ResetTime:    now.Add(48 * time.Hour),
+		},
+	}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	output := buf.String()
+
+	if !strings.Contains(output, "Projected") {
+		t.Error("Output missing 'Projected' header")
+	}
+	if !strings.Contains(output, "100% in 40m") {
+		t.Errorf("Output missing exhaustion projection\n%s", output)
+	}
+	if !strings.Contains(output, "ok until reset") {
+		t.Errorf("Output missing safe projection\n%s", output)
+	}
+}
+
+func TestRenderTable_NoProjectedColumnWithoutForecasts(t *testing.T) {
+	rows := []providers.UsageRow{
+		{Provider: "Claude", Label: "5-hour", UsagePercent: 10, ResetTime: time.Now().Add(time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	if strings.Contains(buf.String(), "Projected") {
+		t.Error("Projected column should be hidden when no row has a forecast")
+	}
+}
+
+func TestRenderTable_SpendRows(t *testing.T) {
+	used, limit := 12.5, 50.0
+	rows := []providers.UsageRow{
+		{
+			Provider:     "Claude (a)",
+			Label:        "extra usage",
+			UsagePercent: 25,
+			Spend:        &providers.Spend{Enabled: true, Used: &used, Limit: &limit},
+		},
+		{
+			Provider: "Claude (b)",
+			Label:    "extra usage",
+			Spend:    &providers.Spend{Enabled: false},
+		},
+	}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	output := buf.String()
+
+	if !strings.Contains(output, "25% $12.50 / $50.00") {
+		t.Errorf("Output missing spend against limit\n%s", output)
+	}
+	if !strings.Contains(output, "disabled") {
+		t.Errorf("Output missing disabled extra usage\n%s", output)
+	}
+	if strings.Contains(output, "0%") {
+		t.Errorf("Disabled extra usage should not show a percentage\n%s", output)
+	}
+}
+
+func TestRenderTable_CreditsRow(t *testing.T) {
+	balance := 12.5
+	rows := []providers.UsageRow{{
+		Provider: "Codex (a)",
+		Label:    "credits",
+		Credits: &providers.Credits{
+			HasCredits:          true,
+			Balance:             &balance,
+			ApproxLocalMessages: []int{10, 20},
+			ApproxCloudMessages: []int{2, 4},
+		},
+	}}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	output := buf.String()
+
+	if !strings.Contains(output, "balance 12.5 (~10-20 local, ~2-4 cloud msgs)") {
+		t.Errorf("Output missing credit balance\n%s", output)
+	}
+	if strings.Contains(output, "0%") {
+		t.Errorf("Credits row should not show a percentage\n%s", output)
+	}
+}
+
+func TestRenderTable_QuotaRow(t *testing.T) {
+	rows := []providers.UsageRow{{
+		Provider: "Qwen (a)",
+		Label:    "daily requests",
+		Quota:    &providers.Quota{Limit: 2000, Unit: "requests"},
+	}}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	output := buf.String()
+
+	if !strings.Contains(output, "? / 2000 requests") {
+		t.Errorf("Output missing quota limit\n%s", output)
+	}
+	if strings.Contains(output, "0%") {
+		t.Errorf("Quota row should not show a percentage\n%s", output)
+	}
+}
+
+func TestRenderTable_BlockedRow(t *testing.T) {
+	rows := []providers.UsageRow{{
+		Provider:     "Codex (a)",
+		Label:        "5-hour",
+		UsagePercent: 97,
+		ResetTime:    time.Now().Add(time.Hour),
+		Blocked:      true,
+	}}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	if output := buf.String(); !strings.Contains(output, "97% BLOCKED") {
+		t.Errorf("Output missing blocked marker\n%s", output)
+	}
+}
+
+func TestRenderTable_PlanColumn(t *testing.T) {
+	rows := []providers.UsageRow{
+		{Provider: "Codex (a)", IsGroup: true, Plan: "plus"},
+		{Provider: "", Label: "5-hour", UsagePercent: 10, ResetTime: time.Now().Add(time.Hour)},
+		{Provider: "Claude", Label: "5-hour", UsagePercent: 10, ResetTime: time.Now().Add(time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	lines := strings.Split(buf.String(), "\n")
+
+	if !strings.Contains(lines[0], "Plan") {
+		t.Fatalf("Header missing Plan column\n%s", buf.String())
+	}
+	if !strings.Contains(lines[1], "plus") {
+		t.Errorf("Group row missing plan\n%s", buf.String())
+	}
+}
+
+func TestRenderTable_NoPlanColumnWithoutPlans(t *testing.T) {
+	rows := []providers.UsageRow{
+		{Provider: "Claude", Label: "5-hour", UsagePercent: 10, ResetTime: time.Now().Add(time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	if strings.Contains(buf.String(), "Plan") {
+		t.Errorf("Plan column should be hidden without plans\n%s", buf.String())
+	}
+}
+
+func TestRenderTable_SourceColumn(t *testing.T) {
+	rows := []providers.UsageRow{
+		{Provider: "Codex (a)", Label: "5-hour", Source: "proxy", UsagePercent: 10, ResetTime: time.Now().Add(time.Hour)},
+		{Provider: "Claude (b)", Label: "5-hour", Source: "native", UsagePercent: 10, ResetTime: time.Now().Add(time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	RenderTable(rows, &buf, false)
+	lines := strings.Split(buf.String(), "\n")
+
+	if !strings.Contains(lines[0], "Source") {
+		t.Fatalf("Header missing Source column\n%s", buf.String())
+	}
+	if !strings.Contains(lines[1], "proxy") || !strings.Contains(lines[2], "native") {
+		t.Errorf("Rows missing source\n%s", buf.String())
+	}
+
+	buf.Reset()
+	RenderTable(rows[:1], &buf, false)
+	if strings.Contains(buf.String(), "Source") {
+		t.Errorf("Source column should be hidden with a single source\n%s", buf.String())
+	}
+}
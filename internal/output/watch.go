@@ -0,0 +1,46 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const ansiClearScreen = "\x1b[H\x1b[2J"
+
+// IsTerminal reports whether w is an interactive terminal.
+func IsTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// ClearScreen moves the cursor to the top-left corner and clears the terminal.
+func ClearScreen(w io.Writer) {
+	fmt.Fprint(w, ansiClearScreen)
+}
+
+// PrintWatchStatus prints the dimmed watch-mode footer with a countdown to the next refresh.
+func PrintWatchStatus(w io.Writer, updatedAt time.Time, next time.Duration) {
+	status := fmt.Sprintf("Updated %s · next refresh in %s · Ctrl-C to quit",
+		updatedAt.Local().Format("15:04:05"), formatCountdown(next))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, colorize(isColorEnabled(w), status, ansiDim))
+}
+
+// formatCountdown renders a duration as "Xm Ys" or "Ys", rounding up to whole seconds.
+func formatCountdown(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds >= 60 {
+		return fmt.Sprintf("%dm %ds", seconds/60, seconds%60)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
@@ -0,0 +1,41 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{-5 * time.Second, "0s"},
+		{1500 * time.Millisecond, "2s"},
+		{59 * time.Second, "59s"},
+		{60 * time.Second, "1m 0s"},
+		{125 * time.Second, "2m 5s"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCountdown(tt.in); got != tt.want {
+			t.Errorf("formatCountdown(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPrintWatchStatus_NoColorForBuffer(t *testing.T) {
+	var buf bytes.Buffer
+	PrintWatchStatus(&buf, time.Now(), 42*time.Second)
+	out := buf.String()
+
+	if !strings.Contains(out, "next refresh in 42s") {
+		t.Errorf("expected countdown in status, got %q", out)
+	}
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no ANSI codes for non-terminal writer, got %q", out)
+	}
+}
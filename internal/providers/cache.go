@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charlieyou/aim/internal/filelock"
+)
+
+// cacheEntry is a value kept in a persistedCache.
+type cacheEntry interface {
+	// expiresAt reports when the entry stops being reused.
+	expiresAt() time.Time
+}
+
+// persistedCache remembers per-account lookups, optionally persisting them
+// so one-shot runs skip the extra round trip. The zero value is a
+// memory-only cache.
+type persistedCache[T cacheEntry] struct {
+	mu      sync.Mutex
+	path    string // cache file; empty keeps the cache in memory only
+	loaded  bool
+	entries map[string]T
+}
+
+// defaultCachePath returns $XDG_CACHE_HOME/aim/<name>, or "" when no cache
+// directory is available.
+func defaultCachePath(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aim", name)
+}
+
+// get returns the entry for key unless it is missing or expired.
+func (c *persistedCache[T]) get(key string, now time.Time) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt()) {
+		var zero T
+		return zero, false
+	}
+	return entry, true
+}
+
+// latest returns the entry for key however old, or the zero value.
+func (c *persistedCache[T]) latest(key string) T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	return c.entries[key]
+}
+
+func (c *persistedCache[T]) put(key string, entry T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	c.entries[key] = entry
+	if c.path == "" {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		debugf("cache", "failed to create cache directory: %v", err)
+		return
+	}
+	unlock, err := lockCacheFile(c.path)
+	if err != nil {
+		debugf("cache", "%v", err)
+		return
+	}
+	defer unlock()
+
+	// Other aim processes may have written entries since this one loaded
+	// the file, so merge into what is on disk rather than overwrite it.
+	entries := readCacheFile[T](c.path)
+	entries[key] = entry
+	for k, v := range entries {
+		if _, ok := c.entries[k]; !ok {
+			c.entries[k] = v
+		}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	if err := writeFileAtomic(c.path, data, 0o600); err != nil {
+		debugf("cache", "failed to write cache %s: %v", c.path, err)
+	}
+}
+
+// loadLocked reads the cache file once.
+func (c *persistedCache[T]) loadLocked() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = make(map[string]T)
+	if c.path == "" {
+		return
+	}
+	c.entries = readCacheFile[T](c.path)
+}
+
+// readCacheFile reads the cache file at path. A missing or corrupt file
+// reads as an empty cache.
+func readCacheFile[T any](path string) map[string]T {
+	entries := make(map[string]T)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entries
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		debugf("cache", "ignoring unreadable cache %s: %v", path, err)
+		return make(map[string]T)
+	}
+	return entries
+}
+
+// lockCacheFile takes an exclusive advisory lock guarding the
+// read-modify-write of the cache file at path, blocking until it is free.
+// Like credential files, cache files are replaced by rename, so the lock
+// lives in a sidecar file.
+func lockCacheFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache lock %s: %w", lockPath, err)
+	}
+	if err := filelock.Lock(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to lock cache file %s: %w", path, err)
+	}
+	return func() {
+		_ = filelock.Unlock(file)
+		_ = file.Close()
+	}, nil
+}
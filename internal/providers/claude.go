@@ -19,10 +19,12 @@ import (
 const (
 	claudeDefaultBaseURL = "https://api.anthropic.com"
 	claudeAPIPath        = "/api/oauth/usage"
+	claudeProfilePath    = "/api/oauth/profile"
 	claudeAnthropicBeta  = "oauth-2025-04-20"
 	claudeTimeout        = 30 * time.Second
 	claudeTokenURL       = "https://console.anthropic.com/v1/oauth/token"
 	claudeClientID       = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
+	claudeProxyPattern   = "claude-*.json"
 )
 
 var claudeDefaultScopes = []string{
@@ -33,10 +35,15 @@ var claudeDefaultScopes = []string{
 
 // ClaudeProvider implements the Provider interface for Claude (Anthropic)
 type ClaudeProvider struct {
-	homeDir  string
-	baseURL  string
-	tokenURL string
-	client   *http.Client
+	homeDir    string
+	proxyDirs  []string
+	baseURL    string
+	tokenURL   string
+	client     *http.Client
+	profiles   claudeProfileCache
+	refresh    refreshPolicy
+	source     SourceMode
+	management *managementClient
 }
 
 // claudeCredentials represents the ~/.cli-proxy-api/claude-*.json structure.
@@ -60,12 +67,87 @@ type claudeAuth struct {
 	CredentialPath string
 	LoadErr        string
 	IsNative       bool
+	IsRemote       bool   // loaded from the management API, which owns the refresh token
+	NativeEmail    string // signed-in email from ~/.claude.json; native only
+	Organization   string // organization UUID from ~/.claude.json or the profile endpoint; empty if unknown
 }
 
-// claudeUsageResponse represents the API response
+// claudeUsageResponse represents the API response. Windows are null when
+// they do not apply to the account.
 type claudeUsageResponse struct {
-	FiveHour *claudeWindow `json:"five_hour"`
-	SevenDay *claudeWindow `json:"seven_day"`
+	FiveHour          *claudeWindow     `json:"five_hour"`
+	SevenDay          *claudeWindow     `json:"seven_day"`
+	SevenDaySonnet    *claudeWindow     `json:"seven_day_sonnet"`
+	SevenDayOpus      *claudeWindow     `json:"seven_day_opus"`
+	SevenDayOAuthApps *claudeWindow     `json:"seven_day_oauth_apps"`
+	ExtraUsage        *claudeExtraUsage `json:"extra_usage"`
+
+	// Other holds window-shaped entries under keys aim does not know about
+	// yet, keyed by their API name, so new limits show up without a release.
+	Other map[string]*claudeWindow `json:"-"`
+}
+
+// claudeKnownUsageKeys lists the response keys decoded into named fields or
+// that are not usage windows.
+var claudeKnownUsageKeys = map[string]bool{
+	"five_hour":            true,
+	"seven_day":            true,
+	"seven_day_sonnet":     true,
+	"seven_day_opus":       true,
+	"seven_day_oauth_apps": true,
+	"extra_usage":          true,
+}
+
+// UnmarshalJSON decodes the known windows and collects any other non-null
+// object carrying both utilization and resets_at into Other.
+func (r *claudeUsageResponse) UnmarshalJSON(data []byte) error {
+	type known claudeUsageResponse
+	if err := json.Unmarshal(data, (*known)(r)); err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for key, value := range raw {
+		if claudeKnownUsageKeys[key] {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(value, &fields); err != nil || fields == nil {
+			continue
+		}
+		if _, ok := fields["utilization"]; !ok {
+			continue
+		}
+		if _, ok := fields["resets_at"]; !ok {
+			continue
+		}
+		var window claudeWindow
+		if err := json.Unmarshal(value, &window); err != nil {
+			continue
+		}
+		if r.Other == nil {
+			r.Other = make(map[string]*claudeWindow)
+		}
+		r.Other[key] = &window
+	}
+	return nil
+}
+
+// claudeProfileResponse is the subset of the OAuth profile used to derive the
+// plan and identify the account's organization.
+type claudeProfileResponse struct {
+	Account *struct {
+		HasClaudeMax bool `json:"has_claude_max"`
+		HasClaudePro bool `json:"has_claude_pro"`
+	} `json:"account"`
+	Organization *struct {
+		UUID             string `json:"uuid"`
+		OrganizationType string `json:"organization_type"`
+		RateLimitTier    string `json:"rate_limit_tier"`
+	} `json:"organization"`
 }
 
 type claudeRefreshResponse struct {
@@ -81,20 +163,87 @@ type claudeWindow struct {
 	ResetsAt    string  `json:"resets_at"`
 }
 
+// claudeExtraUsage describes pay-as-you-go billing beyond the plan limits.
+// Credit amounts are reported in cents; docs/QUOTA_APIS.md has a sample.
+type claudeExtraUsage struct {
+	IsEnabled    bool     `json:"is_enabled"`
+	MonthlyLimit *float64 `json:"monthly_limit"`
+	UsedCredits  *float64 `json:"used_credits"`
+	Utilization  *float64 `json:"utilization"`
+}
+
+// inUse reports whether extra usage is enabled or has spend this month; a
+// disabled account with nothing spent has no row worth showing.
+func (e *claudeExtraUsage) inUse() bool {
+	return e.IsEnabled || (e.UsedCredits != nil && *e.UsedCredits > 0)
+}
+
+// claudeWindowKeyPrefixes maps window key prefixes to their lengths. The
+// Claude API encodes the window length in the key rather than the value.
+var claudeWindowKeyPrefixes = []struct {
+	prefix string
+	window time.Duration
+}{
+	{"five_hour", 5 * time.Hour},
+	{"seven_day", 7 * 24 * time.Hour},
+}
+
+// claudeWindowLabel converts an API window key into a display label and the
+// window length, e.g. "seven_day_opus" -> "7-day opus", 7 days. Keys with an
+// unknown length keep their name and report a zero duration.
+func claudeWindowLabel(key string) (string, time.Duration) {
+	for _, p := range claudeWindowKeyPrefixes {
+		rest, ok := strings.CutPrefix(key, p.prefix)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "_")) {
+			continue
+		}
+		label := WindowLabel(p.window)
+		if rest != "" {
+			label += " " + strings.ReplaceAll(strings.TrimPrefix(rest, "_"), "_", "-")
+		}
+		return label, p.window
+	}
+	return strings.ReplaceAll(key, "_", "-"), 0
+}
+
+func init() {
+	Register(Registration{
+		Name:  "Claude",
+		Order: 0,
+		New:   func(opts Options) (Provider, error) { return NewClaudeProviderWithOptions(opts) },
+	})
+}
+
 // NewClaudeProvider creates a new ClaudeProvider
 func NewClaudeProvider() (*ClaudeProvider, error) {
+	return NewClaudeProviderWithOptions(Options{})
+}
+
+// NewClaudeProviderWithOptions creates a new ClaudeProvider with the given options
+func NewClaudeProviderWithOptions(opts Options) (*ClaudeProvider, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
+	timeout := claudeTimeout
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+	}
 	return &ClaudeProvider{
-		homeDir:  homeDir,
-		baseURL:  claudeDefaultBaseURL,
-		tokenURL: claudeTokenURL,
-		client: &http.Client{
-			Timeout: claudeTimeout,
-		},
+		homeDir:    homeDir,
+		proxyDirs:  opts.CredentialsDirs,
+		baseURL:    claudeDefaultBaseURL,
+		tokenURL:   claudeTokenURL,
+		client:     client,
+		profiles:   claudeProfileCache{path: defaultCachePath("claude-profile.json")},
+		refresh:    newRefreshPolicy(opts),
+		source:     opts.Source,
+		management: newManagementClient(opts, client),
 	}, nil
 }
 
@@ -105,7 +254,7 @@ func (c *ClaudeProvider) Name() string {
 
 // FetchUsage fetches usage data from the Claude API
 func (c *ClaudeProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
-	accounts, err := c.loadCredentials()
+	accounts, err := c.loadCredentialsContext(ctx)
 	if err != nil {
 		return []UsageRow{{
 			Provider:   c.Name(),
@@ -115,16 +264,12 @@ func (c *ClaudeProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
 	}
 
 	if len(accounts) == 0 {
-		var credPath string
-		if DetectCredentialSource(c.homeDir) == SourceNative {
-			credPath = filepath.Join(c.homeDir, ".claude", ".credentials.json")
-		} else {
-			credPath = filepath.Join(c.homeDir, ".cli-proxy-api", "claude-*.json")
-		}
+		locations := credentialLocations(c.CredentialSources(), c.credentialDirs(), claudeProxyPattern, c.management,
+			filepath.Join(c.homeDir, ".claude", ".credentials.json"))
 		return []UsageRow{{
 			Provider:   c.Name(),
 			IsWarning:  true,
-			WarningMsg: fmt.Sprintf("No credential files found matching %s", credPath),
+			WarningMsg: "No credential files found matching " + locations,
 		}}, nil
 	}
 
@@ -132,38 +277,155 @@ func (c *ClaudeProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
 	for _, account := range accounts {
 		accountRows, err := c.fetchAccountUsage(ctx, account)
 		if err != nil {
-			rows = append(rows, UsageRow{
+			accountRows = []UsageRow{{
 				Provider:   claudeProviderName(account),
 				IsWarning:  true,
 				WarningMsg: claudeWarningMessage(err),
-			})
-			continue
+			}}
 		}
+		setSource(accountRows, claudeAccountSource(account))
 		rows = append(rows, accountRows...)
 	}
 
 	return rows, nil
 }
 
-// loadCredentials loads the access token from the credentials file
-func (c *ClaudeProvider) loadCredentials() ([]claudeAuth, error) {
-	source := DetectCredentialSource(c.homeDir)
-	if source == SourceNative {
-		return c.loadNativeCredentials()
+// RefreshCredentials refreshes proxy tokens that are due without fetching usage.
+func (c *ClaudeProvider) RefreshCredentials(ctx context.Context, force bool) []RefreshResult {
+	accounts, err := c.loadCredentialsContext(ctx)
+	if err != nil {
+		return []RefreshResult{{Provider: c.Name(), Status: RefreshFailed, Message: claudeWarningMessage(err)}}
 	}
 
-	pattern := filepath.Join(c.homeDir, ".cli-proxy-api", "claude-*.json")
+	now := time.Now()
+	results := make([]RefreshResult, 0, len(accounts))
+	for _, account := range accounts {
+		results = append(results, refreshCredential(ctx, c.refresh, force, now, refreshCandidate{
+			providerName:    claudeProviderName(account),
+			loadErr:         account.LoadErr,
+			native:          account.IsNative,
+			remote:          account.IsRemote,
+			hasRefreshToken: account.RefreshToken != "",
+			expiresAt:       account.ExpiresAt,
+			refresh: func(ctx context.Context) (time.Time, bool, error) {
+				_, reused, err := c.refreshOrReuseAccessToken(ctx, account)
+				if err != nil {
+					return time.Time{}, false, err
+				}
+				updated, err := c.loadCredentialFile(account.CredentialPath)
+				if err != nil {
+					return time.Time{}, reused, fmt.Errorf("token refreshed, but reloading credentials failed: %w", err)
+				}
+				return updated.ExpiresAt, reused, nil
+			},
+		}))
+	}
+	return results
+}
 
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to glob credentials: %w", err)
+// DiagnoseCredentials reports on every Claude credential file, proxy and native.
+func (c *ClaudeProvider) DiagnoseCredentials() []CredentialReport {
+	active := c.CredentialSources()
+	using := sourceNames(active, c.proxyDirs, c.management)
+	var reports []CredentialReport
+	var accounts []claudeAuth
+	for _, path := range globCredentialFiles(c.credentialDirs(), claudeProxyPattern) {
+		report, ok := newCredentialReport(c.Name(), path, SourceProxy, active, using)
+		if !ok {
+			continue
+		}
+		if report.Err == "" {
+			if account, err := c.loadCredentialFile(path); err != nil {
+				report.Err = err.Error()
+			} else {
+				report.setLoaded("claude", account.Email, account.ExpiresAt, account.RefreshToken != "")
+				if report.Loaded() {
+					accounts = append(accounts, account)
+				}
+			}
+		}
+		reports = append(reports, report)
 	}
 
-	if len(matches) == 0 {
-		return nil, fmt.Errorf("No credential files found matching %s", pattern)
+	if c.homeDir != "" {
+		nativePath := filepath.Join(c.homeDir, ".claude", ".credentials.json")
+		if report, ok := newCredentialReport(c.Name(), nativePath, SourceNative, active, using); ok {
+			if report.Err == "" {
+				native, _ := c.loadNativeCredentials()
+				for _, account := range native {
+					if account.LoadErr != "" {
+						report.Err = account.LoadErr
+					} else {
+						report.setLoaded("claude", account.NativeEmail, account.ExpiresAt, account.RefreshToken != "")
+						if report.Loaded() {
+							accounts = append(accounts, account)
+						}
+					}
+				}
+			}
+			reports = append(reports, report)
+		}
 	}
 
-	sort.Strings(matches)
+	c.fillOrganizations(accounts)
+	markDuplicates(reports, duplicateCredentials(accounts, claudeAccountIdentity(accounts), claudeCredentialRank))
+	return reports
+}
+
+// credentialDirs returns the CLIProxyAPI credential directories for this provider.
+func (c *ClaudeProvider) credentialDirs() []string {
+	return ProxyCredentialDirs(c.homeDir, c.proxyDirs)
+}
+
+// CredentialSources returns the credential sources loaded under the
+// configured source mode, proxy first.
+func (c *ClaudeProvider) CredentialSources() []CredentialSource {
+	return c.source.sources(c.credentialDirs(), claudeProxyPattern, c.management != nil)
+}
+
+// loadCredentials is loadCredentialsContext for callers without a context.
+func (c *ClaudeProvider) loadCredentials() ([]claudeAuth, error) {
+	return c.loadCredentialsContext(context.Background())
+}
+
+// loadCredentialsContext loads accounts from every selected credential source,
+// keeping one credential per account when several files hold the same one.
+func (c *ClaudeProvider) loadCredentialsContext(ctx context.Context) ([]claudeAuth, error) {
+	var accounts []claudeAuth
+	for _, source := range c.CredentialSources() {
+		var loaded []claudeAuth
+		var err error
+		switch source {
+		case SourceNative:
+			loaded, err = c.loadNativeCredentials()
+		case SourceManagement:
+			loaded, err = c.loadManagementCredentials(ctx)
+		default:
+			loaded, err = c.loadProxyCredentials()
+		}
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, loaded...)
+	}
+	c.fillOrganizations(accounts)
+	return dedupeAccounts(c.Name(), accounts, claudeAccountIdentity(accounts), claudeCredentialRank), nil
+}
+
+// fillOrganizations sets each account's organization, when its file does not
+// name one, to the last one seen in a profile lookup however old: a
+// credential file does not move between organizations.
+func (c *ClaudeProvider) fillOrganizations(accounts []claudeAuth) {
+	for i := range accounts {
+		if accounts[i].Organization == "" {
+			accounts[i].Organization = c.profiles.latest(claudeProfileKey(accounts[i])).Organization
+		}
+	}
+}
+
+// loadProxyCredentials loads every claude-*.json file in the proxy directories.
+func (c *ClaudeProvider) loadProxyCredentials() ([]claudeAuth, error) {
+	matches := globCredentialFiles(c.credentialDirs(), claudeProxyPattern)
 
 	accounts := make([]claudeAuth, 0, len(matches))
 	for _, credsPath := range matches {
@@ -184,12 +446,47 @@ func (c *ClaudeProvider) loadCredentials() ([]claudeAuth, error) {
 	return accounts, nil
 }
 
+// loadManagementCredentials loads claude auth files from the CLIProxyAPI
+// management API. The proxy keeps refreshing these tokens, so aim drops the
+// refresh token rather than racing it for a rotation.
+func (c *ClaudeProvider) loadManagementCredentials(ctx context.Context) ([]claudeAuth, error) {
+	if c.management == nil {
+		return nil, fmt.Errorf("no CLIProxyAPI management URL configured")
+	}
+	files, err := c.management.authFiles(ctx, "claude", claudeProxyPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]claudeAuth, 0, len(files))
+	for _, file := range files {
+		account, err := c.parseCredentials(file.Data, file.Location, file.Name)
+		if err != nil {
+			sourceName := extractClaudeEmailFromFilename(file.Name)
+			account = claudeAuth{
+				Email:          sourceName,
+				SourceName:     sourceName,
+				CredentialPath: file.Location,
+				LoadErr:        err.Error(),
+			}
+		}
+		account.RefreshToken = ""
+		account.IsRemote = true
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
 func (c *ClaudeProvider) loadCredentialFile(credsPath string) (claudeAuth, error) {
 	data, err := os.ReadFile(credsPath)
 	if err != nil {
 		return claudeAuth{}, fmt.Errorf("failed to read credentials file %s: %w", credsPath, err)
 	}
+	return c.parseCredentials(data, credsPath, filepath.Base(credsPath))
+}
 
+// parseCredentials parses a claude-*.json auth file read from location.
+func (c *ClaudeProvider) parseCredentials(data []byte, credsPath, name string) (claudeAuth, error) {
 	var creds claudeCredentials
 	if err := json.Unmarshal(data, &creds); err != nil {
 		return claudeAuth{}, fmt.Errorf("failed to parse credentials file %s: %w", credsPath, err)
@@ -203,15 +500,18 @@ func (c *ClaudeProvider) loadCredentialFile(credsPath string) (claudeAuth, error
 		return claudeAuth{}, fmt.Errorf("no access token found in credentials")
 	}
 
-	sourceName := extractClaudeEmailFromFilename(filepath.Base(credsPath))
+	sourceName := extractClaudeEmailFromFilename(name)
 	email := creds.Email
 	if email == "" {
 		email = sourceName
 	}
 
+	expiresAt, _ := parseCredentialTime(creds.Expired)
+
 	return claudeAuth{
 		AccessToken:    creds.AccessToken,
 		RefreshToken:   creds.RefreshToken,
+		ExpiresAt:      expiresAt,
 		Email:          email,
 		SourceName:     sourceName,
 		CredentialPath: credsPath,
@@ -229,6 +529,29 @@ type nativeOAuth struct {
 	ExpiresAt    int64  `json:"expiresAt"`
 }
 
+// claudeNativeConfig is the part of Claude Code's ~/.claude.json that
+// identifies the signed-in account.
+type claudeNativeConfig struct {
+	OAuthAccount *struct {
+		EmailAddress     string `json:"emailAddress"`
+		OrganizationUUID string `json:"organizationUuid"`
+	} `json:"oauthAccount"`
+}
+
+// nativeAccount returns the email and organization Claude Code is signed in
+// with, or empty strings if ~/.claude.json is missing or does not record them.
+func (c *ClaudeProvider) nativeAccount() (email, organization string) {
+	data, err := os.ReadFile(filepath.Join(c.homeDir, ".claude.json"))
+	if err != nil {
+		return "", ""
+	}
+	var cfg claudeNativeConfig
+	if err := json.Unmarshal(data, &cfg); err != nil || cfg.OAuthAccount == nil {
+		return "", ""
+	}
+	return cfg.OAuthAccount.EmailAddress, cfg.OAuthAccount.OrganizationUUID
+}
+
 // loadNativeCredentials loads credentials from ~/.claude/.credentials.json.
 func (c *ClaudeProvider) loadNativeCredentials() ([]claudeAuth, error) {
 	// Guard against empty homeDir to avoid scanning current directory in CI/sandbox
@@ -279,6 +602,7 @@ func (c *ClaudeProvider) loadNativeCredentials() ([]claudeAuth, error) {
 	if creds.ClaudeAIOAuth.ExpiresAt > 0 {
 		auth.ExpiresAt = time.UnixMilli(creds.ClaudeAIOAuth.ExpiresAt)
 	}
+	auth.NativeEmail, auth.Organization = c.nativeAccount()
 
 	return []claudeAuth{auth}, nil
 }
@@ -291,7 +615,28 @@ func (c *ClaudeProvider) fetchAccountUsage(ctx context.Context, account claudeAu
 		return nil, fmt.Errorf("no access token found in credentials")
 	}
 
-	resp, err := c.fetchUsageFromAPI(ctx, account.AccessToken)
+	token := account.AccessToken
+	// Refresh proxy tokens that are about to expire instead of waiting for a 401.
+	// The refresh token may be rotated, so it is used at most once per run.
+	refreshed := false
+	if !account.IsNative && account.RefreshToken != "" && c.refresh.dueBefore(account.ExpiresAt, time.Now()) {
+		claudeDebugf("token expires at %s, refreshing before usage request", account.ExpiresAt.Format(time.RFC3339))
+		refreshedToken, refreshErr := c.refreshAccessToken(ctx, account)
+		switch {
+		case refreshErr == nil:
+			token = refreshedToken
+		case time.Now().Before(account.ExpiresAt):
+			// The current token is still valid, so a token endpoint outage
+			// should not hide usage until it actually expires.
+			claudeDebugf("token refresh failed, using current token until it expires: %v", refreshErr)
+		default:
+			claudeDebugf("token refresh failed: %v", refreshErr)
+			return nil, refreshErr
+		}
+		refreshed = true
+	}
+
+	resp, err := c.fetchUsageFromAPI(ctx, token)
 	if err != nil {
 		var statusErr APIStatusError
 		if errors.As(err, &statusErr) {
@@ -304,12 +649,13 @@ func (c *ClaudeProvider) fetchAccountUsage(ctx context.Context, account claudeAu
 				return nil, fmt.Errorf("token expired. Re-authenticate with claude to refresh")
 			}
 			// For proxy credentials, attempt refresh if we have a refresh token
-			if account.RefreshToken != "" {
+			if account.RefreshToken != "" && !refreshed && !c.refresh.disabled {
 				claudeDebugf("attempting token refresh after status=%d", statusErr.StatusCode)
 				refreshedToken, refreshErr := c.refreshAccessToken(ctx, account)
 				if refreshErr == nil {
 					claudeDebugf("token refresh succeeded, retrying usage API")
-					resp, err = c.fetchUsageFromAPI(ctx, refreshedToken)
+					token = refreshedToken
+					resp, err = c.fetchUsageFromAPI(ctx, token)
 				} else {
 					claudeDebugf("token refresh failed: %v", refreshErr)
 					err = refreshErr
@@ -322,25 +668,177 @@ func (c *ClaudeProvider) fetchAccountUsage(ctx context.Context, account claudeAu
 	}
 
 	providerName := claudeProviderName(account)
-	return c.parseUsageResponse(resp, providerName), nil
+	rows := c.parseUsageResponse(resp, providerName)
+	setPlan(rows, c.accountPlan(ctx, account, token))
+	return rows, nil
+}
+
+// accountPlan returns the account's plan from the OAuth profile endpoint,
+// cached per account. Lookup failures are logged and cached too, leaving the
+// plan unknown until the cache entry expires.
+func (c *ClaudeProvider) accountPlan(ctx context.Context, account claudeAuth, token string) string {
+	key := claudeProfileKey(account)
+	now := time.Now()
+	if info, ok := c.profiles.get(key, now); ok {
+		return info.Plan
+	}
+
+	// A failed lookup keeps the organization from an earlier one.
+	info := claudeProfileInfo{FetchedAt: now, Organization: c.profiles.latest(key).Organization}
+	profile, err := c.fetchProfile(ctx, token)
+	if err != nil {
+		claudeDebugf("profile lookup failed for %s: %v", claudeProviderName(account), err)
+	} else {
+		info.Plan = claudePlan(profile)
+		if profile.Organization != nil {
+			info.Organization = profile.Organization.UUID
+		}
+	}
+	c.profiles.put(key, info)
+	return info.Plan
+}
+
+// claudeProfileKey returns the key an account's profile is cached under.
+func claudeProfileKey(account claudeAuth) string {
+	if account.CredentialPath != "" {
+		return account.CredentialPath
+	}
+	return account.Email
 }
 
+func (c *ClaudeProvider) fetchProfile(ctx context.Context, token string) (*claudeProfileResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+claudeProfilePath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", UserAgent())
+	req.Header.Set("anthropic-beta", claudeAnthropicBeta)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, APIStatusError{StatusCode: resp.StatusCode, Body: TruncateBody(body, 200)}
+	}
+
+	var profile claudeProfileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile response: %w", err)
+	}
+	return &profile, nil
+}
+
+// claudePlan derives a short plan name such as "max 20x", "pro" or "free".
+// It returns "" when the profile carries no plan information.
+func claudePlan(profile *claudeProfileResponse) string {
+	if org := profile.Organization; org != nil {
+		tier := strings.ToLower(org.RateLimitTier)
+		for _, multiplier := range []string{"20x", "5x"} {
+			if strings.Contains(tier, "max_"+multiplier) {
+				return "max " + multiplier
+			}
+		}
+	}
+	if account := profile.Account; account != nil {
+		switch {
+		case account.HasClaudeMax:
+			return "max"
+		case account.HasClaudePro:
+			return "pro"
+		}
+	}
+	if org := profile.Organization; org != nil && org.OrganizationType != "" {
+		return strings.TrimPrefix(strings.ToLower(org.OrganizationType), "claude_")
+	}
+	if profile.Account != nil {
+		return "free"
+	}
+	return ""
+}
+
+// refreshAccessToken refreshes the access token in the credential file and
+// returns it.
 func (c *ClaudeProvider) refreshAccessToken(ctx context.Context, creds claudeAuth) (string, error) {
+	token, _, err := c.refreshOrReuseAccessToken(ctx, creds)
+	return token, err
+}
+
+// refreshOrReuseAccessToken is refreshAccessToken that also reports whether
+// another process had already refreshed the token, in which case the token
+// it wrote is returned without calling the token endpoint.
+func (c *ClaudeProvider) refreshOrReuseAccessToken(ctx context.Context, creds claudeAuth) (string, bool, error) {
 	if creds.IsNative {
-		return "", fmt.Errorf("token expired. Re-authenticate with claude to refresh")
+		return "", false, fmt.Errorf("token expired. Re-authenticate with claude to refresh")
+	}
+	if creds.IsRemote {
+		return "", false, fmt.Errorf("token expired. CLIProxyAPI refreshes management API credentials")
 	}
 	if creds.RefreshToken == "" {
-		return "", fmt.Errorf("refresh token not available")
+		return "", false, fmt.Errorf("refresh token not available")
+	}
+	if creds.CredentialPath == "" {
+		return "", false, fmt.Errorf("credential path not available for refresh")
+	}
+
+	unlock, err := lockCredentialFile(ctx, creds.CredentialPath)
+	if err != nil {
+		return "", false, err
+	}
+	defer unlock()
+
+	// CLIProxyAPI or another aim process may have rotated the tokens since they
+	// were loaded. Reuse a newer access token rather than spending the refresh
+	// token, and never refresh with one that has already been used.
+	if current, err := c.loadCredentialFile(creds.CredentialPath); err == nil {
+		if current.AccessToken != creds.AccessToken && !c.refresh.dueBefore(current.ExpiresAt, time.Now()) {
+			claudeDebugf("credentials were refreshed concurrently, reusing newer token")
+			return current.AccessToken, true, nil
+		}
+		if current.RefreshToken != "" {
+			creds.RefreshToken = current.RefreshToken
+		}
+	}
+
+	refreshResp, err := c.requestTokenRefresh(ctx, creds.RefreshToken, creds.Scopes)
+	if isInvalidGrant(err) {
+		// CLIProxyAPI does not take aim's lock, so it may have spent the
+		// refresh token after it was read above. Pick up what it wrote.
+		if current, loadErr := c.loadCredentialFile(creds.CredentialPath); loadErr == nil &&
+			current.RefreshToken != "" && current.RefreshToken != creds.RefreshToken {
+			if current.AccessToken != creds.AccessToken && !c.refresh.dueBefore(current.ExpiresAt, time.Now()) {
+				claudeDebugf("refresh token was rotated by another process, reusing newer token")
+				return current.AccessToken, true, nil
+			}
+			claudeDebugf("refresh token was rotated by another process, retrying with the newer one")
+			refreshResp, err = c.requestTokenRefresh(ctx, current.RefreshToken, creds.Scopes)
+		}
+	}
+	if err != nil {
+		return "", false, err
 	}
 
-	scopes := creds.Scopes
+	if err := updateClaudeCredentialFile(creds.CredentialPath, refreshResp); err != nil {
+		return "", false, err
+	}
+
+	return refreshResp.AccessToken, false, nil
+}
+
+// requestTokenRefresh exchanges refreshToken for a new access token.
+func (c *ClaudeProvider) requestTokenRefresh(ctx context.Context, refreshToken string, scopes []string) (claudeRefreshResponse, error) {
 	if len(scopes) == 0 {
 		scopes = claudeDefaultScopes
 	}
 
 	payload := map[string]string{
 		"grant_type":    "refresh_token",
-		"refresh_token": creds.RefreshToken,
+		"refresh_token": refreshToken,
 		"client_id":     claudeClientID,
 	}
 	if len(scopes) > 0 {
@@ -349,7 +847,7 @@ func (c *ClaudeProvider) refreshAccessToken(ctx context.Context, creds claudeAut
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to encode refresh request: %w", err)
+		return claudeRefreshResponse{}, fmt.Errorf("failed to encode refresh request: %w", err)
 	}
 
 	tokenURL := c.tokenURL
@@ -359,7 +857,7 @@ func (c *ClaudeProvider) refreshAccessToken(ctx context.Context, creds claudeAut
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to create refresh request: %w", err)
+		return claudeRefreshResponse{}, fmt.Errorf("failed to create refresh request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", UserAgent())
@@ -367,19 +865,19 @@ func (c *ClaudeProvider) refreshAccessToken(ctx context.Context, creds claudeAut
 	resp, err := c.client.Do(req)
 	if err != nil {
 		claudeDebugf("token refresh request failed: %v", err)
-		return "", fmt.Errorf("token refresh request failed: %w", err)
+		return claudeRefreshResponse{}, fmt.Errorf("token refresh request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		claudeDebugf("failed to read token refresh response: %v", err)
-		return "", fmt.Errorf("failed to read token refresh response: %w", err)
+		return claudeRefreshResponse{}, fmt.Errorf("failed to read token refresh response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		claudeDebugf("token refresh non-200 status=%d body=%q", resp.StatusCode, debugBody(respBody))
-		return "", APIStatusError{
+		return claudeRefreshResponse{}, APIStatusError{
 			StatusCode: resp.StatusCode,
 			Body:       TruncateBody(respBody, 200),
 		}
@@ -388,21 +886,13 @@ func (c *ClaudeProvider) refreshAccessToken(ctx context.Context, creds claudeAut
 	var refreshResp claudeRefreshResponse
 	if err := json.Unmarshal(respBody, &refreshResp); err != nil {
 		claudeDebugf("failed to parse token refresh response: %v body=%q", err, debugBody(respBody))
-		return "", fmt.Errorf("failed to parse token refresh response: %w", err)
+		return claudeRefreshResponse{}, fmt.Errorf("failed to parse token refresh response: %w", err)
 	}
 	if refreshResp.AccessToken == "" {
 		claudeDebugf("token refresh response missing access_token")
-		return "", fmt.Errorf("token refresh failed: empty access_token")
-	}
-
-	if creds.CredentialPath == "" {
-		return "", fmt.Errorf("credential path not available for refresh")
+		return claudeRefreshResponse{}, fmt.Errorf("token refresh failed: empty access_token")
 	}
-	if err := updateClaudeCredentialFile(creds.CredentialPath, refreshResp); err != nil {
-		return "", err
-	}
-
-	return refreshResp.AccessToken, nil
+	return refreshResp, nil
 }
 
 func updateClaudeCredentialFile(path string, refreshResp claudeRefreshResponse) error {
@@ -459,55 +949,93 @@ func (c *ClaudeProvider) fetchUsageFromAPI(ctx context.Context, token string) (*
 
 // parseUsageResponse converts the API response to UsageRows
 func (c *ClaudeProvider) parseUsageResponse(resp *claudeUsageResponse, providerName string) []UsageRow {
+	windows := []struct {
+		key    string
+		window *claudeWindow
+	}{
+		{"five_hour", resp.FiveHour},
+		{"seven_day", resp.SevenDay},
+		{"seven_day_sonnet", resp.SevenDaySonnet},
+		{"seven_day_opus", resp.SevenDayOpus},
+		{"seven_day_oauth_apps", resp.SevenDayOAuthApps},
+	}
+	otherKeys := make([]string, 0, len(resp.Other))
+	for key := range resp.Other {
+		otherKeys = append(otherKeys, key)
+	}
+	sort.Strings(otherKeys)
+	for _, key := range otherKeys {
+		windows = append(windows, struct {
+			key    string
+			window *claudeWindow
+		}{key, resp.Other[key]})
+	}
+
 	var rows []UsageRow
+	for _, w := range windows {
+		if w.window == nil {
+			continue
+		}
+		label, length := claudeWindowLabel(w.key)
+		rows = append(rows, claudeWindowRow(providerName, label, length, w.window))
+	}
 
-	if resp.FiveHour == nil && resp.SevenDay == nil {
+	if len(rows) == 0 {
 		return []UsageRow{{
 			Provider:   providerName,
 			IsWarning:  true,
 			WarningMsg: "no usage quota (free tier or inactive)",
 		}}
 	}
+	if resp.ExtraUsage != nil && resp.ExtraUsage.inUse() {
+		rows = append(rows, claudeExtraUsageRow(providerName, resp.ExtraUsage))
+	}
+	return rows
+}
 
-	if resp.FiveHour != nil {
-		resetTime, err := parseClaudeResetTime(resp.FiveHour.ResetsAt)
-		if err != nil {
-			rows = append(rows, UsageRow{
-				Provider:   providerName,
-				Label:      "5-hour",
-				IsWarning:  true,
-				WarningMsg: fmt.Sprintf("Parse error: invalid reset time format: %v", err),
-			})
-		} else {
-			rows = append(rows, UsageRow{
-				Provider:     providerName,
-				Label:        "5-hour",
-				UsagePercent: resp.FiveHour.Utilization,
-				ResetTime:    resetTime,
-			})
+func claudeWindowRow(providerName, label string, length time.Duration, window *claudeWindow) UsageRow {
+	resetTime, err := parseClaudeResetTime(window.ResetsAt)
+	if err != nil {
+		return UsageRow{
+			Provider:   providerName,
+			Label:      label,
+			IsWarning:  true,
+			WarningMsg: fmt.Sprintf("Parse error: invalid reset time format: %v", err),
 		}
 	}
+	return UsageRow{
+		Provider:     providerName,
+		Label:        label,
+		UsagePercent: window.Utilization,
+		ResetTime:    resetTime,
+		Window:       length,
+		Blocked:      window.Utilization >= 100,
+	}
+}
 
-	if resp.SevenDay != nil {
-		resetTime, err := parseClaudeResetTime(resp.SevenDay.ResetsAt)
-		if err != nil {
-			rows = append(rows, UsageRow{
-				Provider:   providerName,
-				Label:      "7-day",
-				IsWarning:  true,
-				WarningMsg: fmt.Sprintf("Parse error: invalid reset time format: %v", err),
-			})
-		} else {
-			rows = append(rows, UsageRow{
-				Provider:     providerName,
-				Label:        "7-day",
-				UsagePercent: resp.SevenDay.Utilization,
-				ResetTime:    resetTime,
-			})
-		}
+func claudeExtraUsageRow(providerName string, extra *claudeExtraUsage) UsageRow {
+	spend := &Spend{Enabled: extra.IsEnabled}
+	if extra.UsedCredits != nil {
+		used := *extra.UsedCredits / 100
+		spend.Used = &used
+	}
+	if extra.MonthlyLimit != nil {
+		limit := *extra.MonthlyLimit / 100
+		spend.Limit = &limit
 	}
 
-	return rows
+	row := UsageRow{
+		Provider: providerName,
+		Label:    "extra usage",
+		Spend:    spend,
+	}
+	switch {
+	case extra.Utilization != nil:
+		row.UsagePercent = *extra.Utilization
+	case spend.Used != nil && spend.Limit != nil && *spend.Limit > 0:
+		row.UsagePercent = *spend.Used / *spend.Limit * 100
+	}
+	return row
 }
 
 func parseClaudeResetTime(raw string) (time.Time, error) {
@@ -543,6 +1071,64 @@ func claudeWarningMessage(err error) string {
 	return err.Error()
 }
 
+// claudeAccountIdentity identifies the upstream account by email. One email
+// can belong to several organizations (e.g. a personal Max plan and a Team
+// seat), so when accounts shows an email under more than one known
+// organization, its accounts are told apart by organization and those whose
+// organization is not yet known are never collapsed. Native credentials only
+// have an email when ~/.claude.json records it.
+func claudeAccountIdentity(accounts []claudeAuth) func(claudeAuth) string {
+	orgs := make(map[string]map[string]bool)
+	for _, creds := range accounts {
+		email := claudeIdentityEmail(creds)
+		if email == "" || creds.Organization == "" {
+			continue
+		}
+		if orgs[email] == nil {
+			orgs[email] = make(map[string]bool)
+		}
+		orgs[email][creds.Organization] = true
+	}
+	return func(creds claudeAuth) string {
+		email := claudeIdentityEmail(creds)
+		if email == "" || len(orgs[email]) < 2 {
+			return email
+		}
+		if creds.Organization == "" {
+			return ""
+		}
+		return email + "/" + creds.Organization
+	}
+}
+
+// claudeIdentityEmail returns the normalized email an account is matched on.
+func claudeIdentityEmail(creds claudeAuth) string {
+	email := creds.Email
+	if creds.IsNative {
+		email = creds.NativeEmail
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func claudeCredentialRank(creds claudeAuth) credentialRank {
+	return credentialRank{
+		path:      creds.CredentialPath,
+		failed:    creds.LoadErr != "",
+		native:    creds.IsNative,
+		expiresAt: creds.ExpiresAt,
+	}
+}
+
+func claudeAccountSource(creds claudeAuth) CredentialSource {
+	if creds.IsNative {
+		return SourceNative
+	}
+	if creds.IsRemote {
+		return SourceManagement
+	}
+	return SourceProxy
+}
+
 func claudeProviderName(creds claudeAuth) string {
 	label := strings.TrimSpace(creds.Email)
 	if label == "" {
@@ -0,0 +1,24 @@
+package providers
+
+import "time"
+
+// claudeProfileTTL is how long a looked-up profile is reused. Plans change
+// rarely, so aim only asks about once an hour, even across one-shot runs.
+const claudeProfileTTL = time.Hour
+
+// claudeProfileInfo is what aim keeps from the OAuth profile of an account.
+// A failed lookup is cached with an empty plan so it is not retried on
+// every run.
+type claudeProfileInfo struct {
+	Plan         string    `json:"plan"`
+	Organization string    `json:"organization,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+func (i claudeProfileInfo) expiresAt() time.Time {
+	return i.FetchedAt.Add(claudeProfileTTL)
+}
+
+// claudeProfileCache remembers profile lookups per account, persisted to
+// $XDG_CACHE_HOME/aim/claude-profile.json by default.
+type claudeProfileCache = persistedCache[claudeProfileInfo]
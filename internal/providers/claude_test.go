@@ -3,11 +3,13 @@ package providers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -26,6 +28,11 @@ func TestClaudeProvider_FetchUsage_Success(t *testing.T) {
 		if r.Method != http.MethodGet {
 			t.Errorf("expected GET, got %s", r.Method)
 		}
+		if r.URL.Path == claudeProfilePath {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"account": {"has_claude_max": true}, "organization": {"rate_limit_tier": "default_claude_max_20x"}}`))
+			return
+		}
 		if r.URL.Path != claudeAPIPath {
 			t.Errorf("expected path %s, got %s", claudeAPIPath, r.URL.Path)
 		}
@@ -124,6 +131,172 @@ func TestClaudeProvider_FetchUsage_Success(t *testing.T) {
 	if !rows[1].ResetTime.Equal(expectedSevenDay) {
 		t.Errorf("row[1].ResetTime = %v, want %v", rows[1].ResetTime, expectedSevenDay)
 	}
+
+	for i, row := range rows {
+		if row.Plan != "max 20x" {
+			t.Errorf("row[%d].Plan = %q, want %q", i, row.Plan, "max 20x")
+		}
+	}
+}
+
+func TestClaudeUsageResponse_ModelWindows(t *testing.T) {
+	body := `{
+		"five_hour": {"utilization": 24.0, "resets_at": "2026-01-02T19:59:59+00:00"},
+		"seven_day": {"utilization": 36.0, "resets_at": "2026-01-08T06:59:59+00:00"},
+		"seven_day_sonnet": {"utilization": 1.0, "resets_at": "2026-01-08T14:59:59+00:00"},
+		"seven_day_opus": {"utilization": 92.0, "resets_at": "2026-01-08T14:59:59+00:00"},
+		"seven_day_oauth_apps": null,
+		"iguana_necktie": null,
+		"seven_day_haiku": {"utilization": 5.0, "resets_at": "2026-01-08T14:59:59+00:00"},
+		"extra_usage": {"is_enabled": false, "monthly_limit": null, "used_credits": null, "utilization": null}
+	}`
+
+	var resp claudeUsageResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	rows := (&ClaudeProvider{}).parseUsageResponse(&resp, "Claude (a@example.com)")
+	want := []struct {
+		label   string
+		percent float64
+	}{
+		{"5-hour", 24},
+		{"7-day", 36},
+		{"7-day sonnet", 1},
+		{"7-day opus", 92},
+		{"7-day haiku", 5},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %d: %+v", len(want), len(rows), rows)
+	}
+	for i, w := range want {
+		if rows[i].Label != w.label || rows[i].UsagePercent != w.percent || rows[i].IsWarning {
+			t.Errorf("row[%d] = %q %.0f%% (warning=%v), want %q %.0f%%",
+				i, rows[i].Label, rows[i].UsagePercent, rows[i].IsWarning, w.label, w.percent)
+		}
+	}
+}
+
+func TestClaudeUsageResponse_ExtraUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		extra       string
+		wantPercent float64
+		wantUsed    string
+		wantLimit   string
+		wantHasPct  bool
+	}{
+		{"disabled with spend", `{"is_enabled": false, "monthly_limit": null, "used_credits": 300, "utilization": null}`, 0, "3.00", "", false},
+		{"enabled with limit", `{"is_enabled": true, "monthly_limit": 5000, "used_credits": 1250, "utilization": 25.0}`, 25, "12.50", "50.00", true},
+		{"enabled without utilization", `{"is_enabled": true, "monthly_limit": 2000, "used_credits": 500, "utilization": null}`, 25, "5.00", "20.00", true},
+		{"enabled unlimited", `{"is_enabled": true, "monthly_limit": null, "used_credits": 740, "utilization": null}`, 0, "7.40", "", false},
+	}
+	t.Run("disabled", func(t *testing.T) {
+		body := `{"five_hour": {"utilization": 1.0, "resets_at": ""},
+			"extra_usage": {"is_enabled": false, "monthly_limit": null, "used_credits": 0, "utilization": null}}`
+		var resp claudeUsageResponse
+		if err := json.Unmarshal([]byte(body), &resp); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if rows := (&ClaudeProvider{}).parseUsageResponse(&resp, "Claude (a)"); len(rows) != 1 {
+			t.Errorf("expected no extra usage row when disabled without spend, got %+v", rows)
+		}
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"five_hour": {"utilization": 1.0, "resets_at": ""}, "extra_usage": ` + tt.extra + `}`
+			var resp claudeUsageResponse
+			if err := json.Unmarshal([]byte(body), &resp); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			rows := (&ClaudeProvider{}).parseUsageResponse(&resp, "Claude (a)")
+			if len(rows) != 2 {
+				t.Fatalf("expected 2 rows, got %d", len(rows))
+			}
+			row := rows[1]
+			if row.Label != "extra usage" || row.Spend == nil {
+				t.Fatalf("row = %+v, want extra usage spend row", row)
+			}
+			if row.UsagePercent != tt.wantPercent {
+				t.Errorf("UsagePercent = %v, want %v", row.UsagePercent, tt.wantPercent)
+			}
+			if row.HasUsagePercent() != tt.wantHasPct {
+				t.Errorf("HasUsagePercent() = %v, want %v", row.HasUsagePercent(), tt.wantHasPct)
+			}
+			format := func(v *float64) string {
+				if v == nil {
+					return ""
+				}
+				return fmt.Sprintf("%.2f", *v)
+			}
+			if got := format(row.Spend.Used); got != tt.wantUsed {
+				t.Errorf("Spend.Used = %q, want %q", got, tt.wantUsed)
+			}
+			if got := format(row.Spend.Limit); got != tt.wantLimit {
+				t.Errorf("Spend.Limit = %q, want %q", got, tt.wantLimit)
+			}
+		})
+	}
+}
+
+// TestClaudeExtraUsageRow_DocumentedSample checks the extra_usage sample in
+// docs/QUOTA_APIS.md: amounts are cents, shown as dollars.
+func TestClaudeExtraUsageRow_DocumentedSample(t *testing.T) {
+	body := `{
+		"five_hour": {"utilization": 24.0, "resets_at": "2026-01-02T19:59:59.600956+00:00"},
+		"extra_usage": {
+			"is_enabled": true,
+			"monthly_limit": 5000,
+			"used_credits": 1234,
+			"utilization": 24.68
+		}
+	}`
+	var resp claudeUsageResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	rows := (&ClaudeProvider{}).parseUsageResponse(&resp, "Claude (a)")
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+	spend := rows[1].Spend
+	if spend == nil || !spend.Enabled || spend.Used == nil || spend.Limit == nil {
+		t.Fatalf("Spend = %+v, want enabled spend with used and limit", spend)
+	}
+	if *spend.Used != 12.34 || *spend.Limit != 50 {
+		t.Errorf("Spend = $%.2f / $%.2f, want $12.34 / $50.00", *spend.Used, *spend.Limit)
+	}
+	if rows[1].UsagePercent != 24.68 {
+		t.Errorf("UsagePercent = %v, want 24.68", rows[1].UsagePercent)
+	}
+}
+
+func TestClaudePlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		want    string
+	}{
+		{"max 5x", `{"account": {"has_claude_max": true}, "organization": {"rate_limit_tier": "default_claude_max_5x"}}`, "max 5x"},
+		{"max without tier", `{"account": {"has_claude_max": true}, "organization": {}}`, "max"},
+		{"pro", `{"account": {"has_claude_pro": true}, "organization": {"organization_type": "claude_pro"}}`, "pro"},
+		{"team", `{"account": {}, "organization": {"organization_type": "claude_team"}}`, "team"},
+		{"free", `{"account": {}}`, "free"},
+		{"unknown", `{}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var profile claudeProfileResponse
+			if err := json.Unmarshal([]byte(tt.profile), &profile); err != nil {
+				t.Fatal(err)
+			}
+			if got := claudePlan(&profile); got != tt.want {
+				t.Errorf("claudePlan() = %q, want %q", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestClaudeProvider_FetchUsage_MissingCreds(t *testing.T) {
@@ -150,7 +323,7 @@ func TestClaudeProvider_FetchUsage_MissingCreds(t *testing.T) {
 	if rows[0].Provider != "Claude" {
 		t.Errorf("row[0].Provider = %q, want %q", rows[0].Provider, "Claude")
 	}
-	// When no proxy credentials exist, DetectCredentialSource returns SourceNative
+	// With no proxy credentials, auto mode falls back to the native file
 	expectedPattern := filepath.Join(tempDir, ".claude", ".credentials.json")
 	expectedMsg := "No credential files found matching " + expectedPattern
 	if rows[0].WarningMsg != expectedMsg {
@@ -358,6 +531,10 @@ func TestClaudeProvider_FetchUsage_RefreshesTokenOn401(t *testing.T) {
 
 	usageCalls := 0
 	usageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == claudeProfilePath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
 		usageCalls++
 		if r.Header.Get("Authorization") != "Bearer new-token" {
 			w.WriteHeader(http.StatusUnauthorized)
@@ -439,6 +616,122 @@ func TestClaudeProvider_FetchUsage_RefreshesTokenOn401(t *testing.T) {
 	}
 }
 
+func TestClaudeProvider_FetchUsage_RefreshesBeforeExpiry(t *testing.T) {
+	tests := []struct {
+		name        string
+		expired     time.Time
+		noRefresh   bool
+		refreshDown bool
+		wantRefresh int
+		wantToken   string
+	}{
+		{name: "expired", expired: time.Now().Add(-time.Hour), wantRefresh: 1, wantToken: "new-token"},
+		{name: "within skew", expired: time.Now().Add(time.Minute), wantRefresh: 1, wantToken: "new-token"},
+		{name: "refresh fails within skew", expired: time.Now().Add(time.Minute), refreshDown: true, wantRefresh: 1, wantToken: "old-token"},
+		{name: "not due", expired: time.Now().Add(time.Hour), wantToken: "old-token"},
+		{name: "no refresh", expired: time.Now().Add(-time.Hour), noRefresh: true, wantToken: "old-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshCalls := 0
+			refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				refreshCalls++
+				if tt.refreshDown {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"access_token":"new-token","refresh_token":"new-refresh","expires_in":3600}`))
+			}))
+			defer refreshServer.Close()
+
+			var usageTokens []string
+			usageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == claudeProfilePath {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				usageTokens = append(usageTokens, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"five_hour": {"utilization": 10.0, "resets_at": "2026-01-02T19:59:59+00:00"}}`))
+			}))
+			defer usageServer.Close()
+
+			tempDir := t.TempDir()
+			credDir := filepath.Join(tempDir, ".cli-proxy-api")
+			if err := os.MkdirAll(credDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			credsJSON := fmt.Sprintf(`{"access_token": "old-token", "refresh_token": "refresh-token", "type": "claude", "expired": %q}`,
+				tt.expired.Format(time.RFC3339))
+			if err := os.WriteFile(filepath.Join(credDir, "claude-user@example.com.json"), []byte(credsJSON), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			p := &ClaudeProvider{
+				homeDir:  tempDir,
+				baseURL:  usageServer.URL,
+				tokenURL: refreshServer.URL,
+				client:   &http.Client{Timeout: 5 * time.Second},
+				refresh:  refreshPolicy{disabled: tt.noRefresh},
+			}
+
+			rows, err := p.FetchUsage(context.Background())
+			if err != nil {
+				t.Fatalf("FetchUsage() error = %v", err)
+			}
+			if len(rows) != 1 || rows[0].IsWarning {
+				t.Fatalf("expected one usage row, got %+v", rows)
+			}
+			if refreshCalls != tt.wantRefresh {
+				t.Errorf("refresh calls = %d, want %d", refreshCalls, tt.wantRefresh)
+			}
+			if len(usageTokens) != 1 || usageTokens[0] != tt.wantToken {
+				t.Errorf("usage requests used tokens %v, want [%s]", usageTokens, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestClaudeRefreshAccessToken_ReusesConcurrentRefresh(t *testing.T) {
+	refreshCalls := 0
+	refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer refreshServer.Close()
+
+	credsPath := filepath.Join(t.TempDir(), "claude-user@example.com.json")
+	creds := claudeAuth{
+		AccessToken:    "old-token",
+		RefreshToken:   "refresh-token",
+		CredentialPath: credsPath,
+	}
+	// Another process refreshed after aim loaded the file.
+	rotated := fmt.Sprintf(`{"access_token": "rotated-token", "refresh_token": "rotated-refresh", "type": "claude", "expired": %q}`,
+		time.Now().Add(time.Hour).Format(time.RFC3339))
+	if err := os.WriteFile(credsPath, []byte(rotated), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &ClaudeProvider{
+		tokenURL: refreshServer.URL,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+
+	token, err := p.refreshAccessToken(context.Background(), creds)
+	if err != nil {
+		t.Fatalf("refreshAccessToken() error = %v", err)
+	}
+	if token != "rotated-token" {
+		t.Errorf("token = %q, want %q", token, "rotated-token")
+	}
+	if refreshCalls != 0 {
+		t.Errorf("expected no refresh calls, got %d", refreshCalls)
+	}
+}
+
 func TestClaudeProvider_FetchUsage_APIError500(t *testing.T) {
 	// Create mock server that returns 500
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -797,6 +1090,154 @@ func TestClaude_LoadCredentials_UsesProxySource(t *testing.T) {
 	}
 }
 
+func TestClaude_LoadCredentials_IgnoresOtherProvidersProxyFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Only Codex is configured in the proxy directory.
+	proxyDir := filepath.Join(tempDir, ".cli-proxy-api")
+	if err := os.MkdirAll(proxyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proxyDir, "codex-user@example.com.json"), []byte(`{"type":"codex"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	claudeDir := filepath.Join(tempDir, ".claude")
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	nativeJSON := `{"claudeAiOauth": {"accessToken": "native-token"}}`
+	if err := os.WriteFile(filepath.Join(claudeDir, ".credentials.json"), []byte(nativeJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &ClaudeProvider{homeDir: tempDir}
+	accounts, err := p.loadCredentials()
+	if err != nil {
+		t.Fatalf("loadCredentials() error = %v", err)
+	}
+	if len(accounts) != 1 || !accounts[0].IsNative {
+		t.Fatalf("expected the native account, got %+v", accounts)
+	}
+}
+
+func TestClaude_LoadCredentials_AllSources(t *testing.T) {
+	tests := []struct {
+		name        string
+		nativeEmail string
+		nativeOrg   string
+		proxyOrg    string
+		wantNative  bool
+	}{
+		{"same account keeps proxy only", "User@Example.com", "org-1", "org-1", false},
+		{"different account keeps both", "other@example.com", "org-1", "org-1", true},
+		{"different organization keeps both", "user@example.com", "org-2", "org-1", true},
+		{"unknown proxy organization matches on email", "user@example.com", "org-1", "", false},
+		{"unknown native account keeps both", "", "", "org-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			proxyDir := filepath.Join(tempDir, ".cli-proxy-api")
+			if err := os.MkdirAll(proxyDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			proxyPath := filepath.Join(proxyDir, "claude-user@example.com.json")
+			proxyJSON := `{"access_token": "proxy-token", "type": "claude", "email": "user@example.com"}`
+			if err := os.WriteFile(proxyPath, []byte(proxyJSON), 0600); err != nil {
+				t.Fatal(err)
+			}
+			claudeDir := filepath.Join(tempDir, ".claude")
+			if err := os.MkdirAll(claudeDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			nativeJSON := `{"claudeAiOauth": {"accessToken": "native-token"}}`
+			if err := os.WriteFile(filepath.Join(claudeDir, ".credentials.json"), []byte(nativeJSON), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if tt.nativeEmail != "" {
+				cfg := fmt.Sprintf(`{"oauthAccount": {"emailAddress": %q, "organizationUuid": %q}}`, tt.nativeEmail, tt.nativeOrg)
+				if err := os.WriteFile(filepath.Join(tempDir, ".claude.json"), []byte(cfg), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p := &ClaudeProvider{homeDir: tempDir, source: SourceModeAll}
+			if tt.proxyOrg != "" {
+				// The proxy account's organization is only known from an
+				// earlier profile lookup.
+				p.profiles.put(proxyPath, claudeProfileInfo{Organization: tt.proxyOrg, FetchedAt: time.Now()})
+			}
+			accounts, err := p.loadCredentials()
+			if err != nil {
+				t.Fatalf("loadCredentials() error = %v", err)
+			}
+			if accounts[0].IsNative || accounts[0].AccessToken != "proxy-token" {
+				t.Errorf("expected the proxy account first, got %+v", accounts[0])
+			}
+			hasNative := len(accounts) == 2 && accounts[1].IsNative
+			if hasNative != tt.wantNative || (!tt.wantNative && len(accounts) != 1) {
+				t.Errorf("got %d accounts (native kept = %v), want native kept = %v", len(accounts), hasNative, tt.wantNative)
+			}
+		})
+	}
+}
+
+func TestClaudeProvider_FetchUsage_CollapsesStaleCopyBeforeProfileLookup(t *testing.T) {
+	var usageTokens []string
+	tokenCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case claudeProfilePath:
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/v1/oauth/token":
+			// The stale copy's refresh token was already spent.
+			tokenCalls++
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+		default:
+			usageTokens = append(usageTokens, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			_, _ = w.Write([]byte(`{"five_hour": {"utilization": 10.0, "resets_at": "2026-01-02T19:59:59+00:00"}}`))
+		}
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	credDir := filepath.Join(tempDir, ".cli-proxy-api")
+	if err := os.MkdirAll(credDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"claude-user@example.com.json": fmt.Sprintf(`{"type": "claude", "email": "user@example.com", "access_token": "fresh", "refresh_token": "r2", "expired": %q}`,
+			time.Now().Add(2*time.Hour).Format(time.RFC3339)),
+		"claude-user@example.com-old.json": fmt.Sprintf(`{"type": "claude", "email": "user@example.com", "access_token": "stale", "refresh_token": "r1", "expired": %q}`,
+			time.Now().Add(-time.Hour).Format(time.RFC3339)),
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(credDir, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &ClaudeProvider{
+		homeDir:  tempDir,
+		baseURL:  server.URL,
+		tokenURL: server.URL + "/v1/oauth/token",
+		client:   &http.Client{Timeout: 5 * time.Second},
+		profiles: claudeProfileCache{path: filepath.Join(tempDir, "cache", "claude-profile.json")},
+	}
+	rows, err := p.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].IsWarning {
+		t.Fatalf("expected one usage row for the account, got %+v", rows)
+	}
+	if len(usageTokens) != 1 || usageTokens[0] != "fresh" || tokenCalls != 0 {
+		t.Errorf("expected only the fresh copy to be used, got usage tokens %q and %d refreshes", usageTokens, tokenCalls)
+	}
+}
+
 func TestClaude_RefreshAccessToken_NativeSkip(t *testing.T) {
 	p := &ClaudeProvider{
 		client: &http.Client{Timeout: 5 * time.Second},
@@ -887,3 +1328,67 @@ func TestClaude_LoadNativeCredentials_MissingExpiresAt(t *testing.T) {
 		t.Errorf("ExpiresAt = %v, want zero (missing expiresAt)", acc.ExpiresAt)
 	}
 }
+
+func TestClaudeProvider_FetchUsage_PersistsProfileLookups(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantPlan string
+		wantOrg  string
+	}{
+		{"success", http.StatusOK, "pro", "org-1"},
+		{"failure", http.StatusInternalServerError, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profileCalls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == claudeProfilePath {
+					profileCalls++
+					w.WriteHeader(tt.status)
+					_, _ = w.Write([]byte(`{"account": {"has_claude_pro": true}, "organization": {"uuid": "org-1"}}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"five_hour": {"utilization": 10.0, "resets_at": "2026-01-02T19:59:59+00:00"}}`))
+			}))
+			defer server.Close()
+
+			tempDir := t.TempDir()
+			credDir := filepath.Join(tempDir, ".cli-proxy-api")
+			if err := os.MkdirAll(credDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			credsPath := filepath.Join(credDir, "claude-user@example.com.json")
+			credsJSON := `{"access_token": "test-token", "type": "claude"}`
+			if err := os.WriteFile(credsPath, []byte(credsJSON), 0600); err != nil {
+				t.Fatal(err)
+			}
+			cachePath := filepath.Join(tempDir, "cache", "claude-profile.json")
+
+			// Each run uses a fresh provider, as separate aim invocations do.
+			for i := 0; i < 2; i++ {
+				p := &ClaudeProvider{
+					homeDir:  tempDir,
+					baseURL:  server.URL,
+					client:   &http.Client{Timeout: 5 * time.Second},
+					profiles: claudeProfileCache{path: cachePath},
+				}
+				rows, err := p.FetchUsage(context.Background())
+				if err != nil {
+					t.Fatalf("FetchUsage() error = %v", err)
+				}
+				if len(rows) != 1 || rows[0].Plan != tt.wantPlan {
+					t.Fatalf("run %d: unexpected rows %+v, want plan %q", i, rows, tt.wantPlan)
+				}
+			}
+			if profileCalls != 1 {
+				t.Errorf("expected 1 profile lookup, got %d", profileCalls)
+			}
+			cache := &claudeProfileCache{path: cachePath}
+			if got := cache.latest(credsPath).Organization; got != tt.wantOrg {
+				t.Errorf("cached organization = %q, want %q", got, tt.wantOrg)
+			}
+		})
+	}
+}
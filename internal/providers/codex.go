@@ -13,7 +13,6 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +22,7 @@ const (
 	codexDefaultBaseURL  = "https://chatgpt.com"
 	codexRefreshURL      = "https://auth.openai.com/oauth/token"
 	codexDefaultClientID = "app_EMoamEEZ73f0CkXaXp7hrann"
+	codexProxyPattern    = "codex-*.json"
 )
 
 // CodexAccount holds credentials for a single Codex account
@@ -41,6 +41,7 @@ type CodexAccount struct {
 	CredentialPath string
 	LoadErr        string // Error message from loading credentials, if any
 	IsNative       bool   // true when loaded from ~/.codex/ instead of proxy
+	IsRemote       bool   // true when loaded from the management API, which owns the refresh token
 }
 
 // codexCredentials represents the JSON structure of credential files
@@ -56,41 +57,103 @@ type codexCredentials struct {
 
 // codexAPIResponse represents the API response structure
 type codexAPIResponse struct {
-	PlanType  string `json:"plan_type"`
-	RateLimit struct {
-		PrimaryWindow struct {
-			UsedPercent float64 `json:"used_percent"`
-			ResetAt     int64   `json:"reset_at"`
-		} `json:"primary_window"`
-		SecondaryWindow struct {
-			UsedPercent float64 `json:"used_percent"`
-			ResetAt     int64   `json:"reset_at"`
-		} `json:"secondary_window"`
-	} `json:"rate_limit"`
+	PlanType            string          `json:"plan_type"`
+	RateLimit           codexRateLimit  `json:"rate_limit"`
+	CodeReviewRateLimit *codexRateLimit `json:"code_review_rate_limit"`
+	Credits             *codexCredits   `json:"credits"`
+}
+
+// codexRateLimit is a rate limit with up to two windows; absent windows are null.
+// Allowed is nil when the server omits it.
+type codexRateLimit struct {
+	Allowed         *bool        `json:"allowed"`
+	LimitReached    bool         `json:"limit_reached"`
+	PrimaryWindow   *codexWindow `json:"primary_window"`
+	SecondaryWindow *codexWindow `json:"secondary_window"`
+}
+
+type codexWindow struct {
+	UsedPercent        float64 `json:"used_percent"`
+	LimitWindowSeconds int64   `json:"limit_window_seconds"`
+	ResetAfterSeconds  int64   `json:"reset_after_seconds"`
+	ResetAt            int64   `json:"reset_at"`
+}
+
+// duration returns the window length reported by the server; zero if absent.
+func (w *codexWindow) duration() time.Duration {
+	return time.Duration(w.LimitWindowSeconds) * time.Second
+}
+
+// resetTime returns when the window resets, preferring the absolute reset_at
+// and falling back to reset_after_seconds. It is zero if neither is reported.
+func (w *codexWindow) resetTime(now time.Time) time.Time {
+	switch {
+	case w.ResetAt > 0:
+		return time.Unix(w.ResetAt, 0)
+	case w.ResetAfterSeconds > 0:
+		return now.Add(time.Duration(w.ResetAfterSeconds) * time.Second)
+	}
+	return time.Time{}
+}
+
+// codexCredits is the prepaid credit block. Balance is a decimal string.
+type codexCredits struct {
+	HasCredits          bool   `json:"has_credits"`
+	Unlimited           bool   `json:"unlimited"`
+	Balance             string `json:"balance"`
+	ApproxLocalMessages []int  `json:"approx_local_messages"`
+	ApproxCloudMessages []int  `json:"approx_cloud_messages"`
 }
 
 // CodexProvider implements the Provider interface for OpenAI Codex
 type CodexProvider struct {
 	homeDir    string
+	proxyDirs  []string
 	baseURL    string
 	refreshURL string
 	client     *http.Client
+	refresh    refreshPolicy
+	source     SourceMode
+	management *managementClient
+}
+
+func init() {
+	Register(Registration{
+		Name:  "Codex",
+		Order: 1,
+		New:   func(opts Options) (Provider, error) { return NewCodexProviderWithOptions(opts) },
+	})
 }
 
 // NewCodexProvider creates a new CodexProvider with default settings
 func NewCodexProvider() (*CodexProvider, error) {
+	return NewCodexProviderWithOptions(Options{})
+}
+
+// NewCodexProviderWithOptions creates a new CodexProvider with the given options
+func NewCodexProviderWithOptions(opts Options) (*CodexProvider, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
+	timeout := 30 * time.Second
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+	}
 	return &CodexProvider{
 		homeDir:    homeDir,
+		proxyDirs:  opts.CredentialsDirs,
 		baseURL:    codexDefaultBaseURL,
 		refreshURL: codexRefreshURL,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:     client,
+		refresh:    newRefreshPolicy(opts),
+		source:     opts.Source,
+		management: newManagementClient(opts, client),
 	}, nil
 }
 
@@ -101,14 +164,16 @@ func (c *CodexProvider) Name() string {
 
 // FetchUsage fetches usage data from all configured Codex accounts
 func (c *CodexProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
-	accounts, err := c.loadCredentials()
+	accounts, err := c.loadCredentialsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(accounts) == 0 {
-		warningMsg := "No credential files found matching ~/.cli-proxy-api/codex-*.json"
-		if DetectCredentialSource(c.homeDir) == SourceNative {
+		sources := c.CredentialSources()
+		locations := credentialLocations(sources, c.credentialDirs(), codexProxyPattern, c.management, "~/.codex/auth.json")
+		warningMsg := "No credential files found matching " + locations
+		if len(sources) == 1 && sources[0] == SourceNative {
 			warningMsg = "No credentials found in ~/.codex/auth.json"
 		}
 		return []UsageRow{{
@@ -122,34 +187,153 @@ func (c *CodexProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
 	for _, account := range accounts {
 		accountRows, err := c.fetchAccountUsage(ctx, account)
 		if err != nil {
-			rows = append(rows, UsageRow{
+			accountRows = []UsageRow{{
 				Provider:   codexProviderName(account),
 				IsWarning:  true,
 				WarningMsg: err.Error(),
 				DebugInfo:  codexAccountDebug(account, ""),
-			})
-			continue
+			}}
 		}
+		setSource(accountRows, codexAccountSource(account))
 		rows = append(rows, accountRows...)
 	}
 
 	return rows, nil
 }
 
-// loadCredentials discovers and loads all Codex credential files
-func (c *CodexProvider) loadCredentials() ([]CodexAccount, error) {
-	source := DetectCredentialSource(c.homeDir)
-	if source == SourceNative {
-		return c.loadNativeCredentials()
+// RefreshCredentials refreshes proxy tokens that are due without fetching usage.
+func (c *CodexProvider) RefreshCredentials(ctx context.Context, force bool) []RefreshResult {
+	accounts, err := c.loadCredentialsContext(ctx)
+	if err != nil {
+		return []RefreshResult{{Provider: c.Name(), Status: RefreshFailed, Message: err.Error()}}
 	}
 
-	pattern := filepath.Join(c.homeDir, ".cli-proxy-api", "codex-*.json")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to glob credentials: %w", err)
+	now := time.Now()
+	results := make([]RefreshResult, 0, len(accounts))
+	for _, account := range accounts {
+		results = append(results, refreshCredential(ctx, c.refresh, force, now, refreshCandidate{
+			providerName:    codexProviderName(account),
+			loadErr:         account.LoadErr,
+			native:          account.IsNative,
+			remote:          account.IsRemote,
+			hasRefreshToken: account.RefreshToken != "",
+			expiresAt:       account.ExpiresAt,
+			refresh: func(ctx context.Context) (time.Time, bool, error) {
+				_, reused, err := c.refreshOrReuseAccessToken(ctx, account)
+				if err != nil {
+					return time.Time{}, false, err
+				}
+				updated, err := c.loadCredentialFile(account.CredentialPath)
+				if err != nil {
+					return time.Time{}, reused, fmt.Errorf("token refreshed, but reloading credentials failed: %w", err)
+				}
+				return updated.ExpiresAt, reused, nil
+			},
+		}))
+	}
+	return results
+}
+
+// DiagnoseCredentials reports on every Codex credential file, proxy and native.
+func (c *CodexProvider) DiagnoseCredentials() []CredentialReport {
+	active := c.CredentialSources()
+	using := sourceNames(active, c.proxyDirs, c.management)
+	var reports []CredentialReport
+	var accounts []CodexAccount
+	for _, path := range globCredentialFiles(c.credentialDirs(), codexProxyPattern) {
+		report, ok := newCredentialReport(c.Name(), path, SourceProxy, active, using)
+		if !ok {
+			continue
+		}
+		if report.Err == "" {
+			if account, err := c.loadCredentialFile(path); err != nil {
+				report.Err = err.Error()
+			} else {
+				report.setLoaded("codex", codexReportAccount(account), account.ExpiresAt, account.RefreshToken != "")
+				if report.Loaded() {
+					accounts = append(accounts, account)
+				}
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	if c.homeDir != "" {
+		nativePath := filepath.Join(c.homeDir, ".codex", "auth.json")
+		if report, ok := newCredentialReport(c.Name(), nativePath, SourceNative, active, using); ok {
+			if report.Err == "" {
+				native, _ := c.loadNativeCredentials()
+				for _, account := range native {
+					if account.LoadErr != "" {
+						report.Err = account.LoadErr
+					} else {
+						report.setLoaded("codex", codexReportAccount(account), account.ExpiresAt, account.RefreshToken != "")
+						if report.Loaded() {
+							accounts = append(accounts, account)
+						}
+					}
+				}
+			}
+			reports = append(reports, report)
+		}
 	}
 
-	sort.Strings(matches)
+	markDuplicates(reports, duplicateCredentials(accounts, codexAccountIdentity, codexCredentialRank))
+	return reports
+}
+
+// codexReportAccount identifies an account by email, falling back to its ID.
+func codexReportAccount(account CodexAccount) string {
+	if account.Email != "" {
+		return account.Email
+	}
+	return account.AccountID
+}
+
+// credentialDirs returns the CLIProxyAPI credential directories for this provider.
+func (c *CodexProvider) credentialDirs() []string {
+	return ProxyCredentialDirs(c.homeDir, c.proxyDirs)
+}
+
+// CredentialSources returns the credential sources loaded under the
+// configured source mode, proxy first.
+func (c *CodexProvider) CredentialSources() []CredentialSource {
+	return c.source.sources(c.credentialDirs(), codexProxyPattern, c.management != nil)
+}
+
+// loadCredentials is loadCredentialsContext for callers without a context.
+func (c *CodexProvider) loadCredentials() ([]CodexAccount, error) {
+	return c.loadCredentialsContext(context.Background())
+}
+
+// loadCredentialsContext loads accounts from every selected credential source,
+// keeping one credential per ChatGPT account when several files hold it.
+func (c *CodexProvider) loadCredentialsContext(ctx context.Context) ([]CodexAccount, error) {
+	var accounts []CodexAccount
+	for _, source := range c.CredentialSources() {
+		var loaded []CodexAccount
+		var err error
+		switch source {
+		case SourceNative:
+			loaded, err = c.loadNativeCredentials()
+		case SourceManagement:
+			loaded, err = c.loadManagementCredentials(ctx)
+		default:
+			loaded, err = c.loadProxyCredentials()
+		}
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, loaded...)
+	}
+	accounts = dedupeAccounts(c.Name(), accounts, codexAccountIdentity, codexCredentialRank)
+	applyCodexDisplayNames(accounts)
+	return accounts, nil
+}
+
+// loadProxyCredentials loads every codex-*.json file in the proxy directories.
+func (c *CodexProvider) loadProxyCredentials() ([]CodexAccount, error) {
+	matches := globCredentialFiles(c.credentialDirs(), codexProxyPattern)
 
 	accounts := make([]CodexAccount, 0, len(matches))
 	for _, path := range matches {
@@ -167,8 +351,37 @@ func (c *CodexProvider) loadCredentials() ([]CodexAccount, error) {
 		accounts = append(accounts, account)
 	}
 
-	applyCodexDisplayNames(accounts)
+	return accounts, nil
+}
+
+// loadManagementCredentials loads codex auth files from the CLIProxyAPI
+// management API. The proxy keeps refreshing these tokens, so aim drops the
+// refresh token rather than racing it for a rotation.
+func (c *CodexProvider) loadManagementCredentials(ctx context.Context) ([]CodexAccount, error) {
+	if c.management == nil {
+		return nil, fmt.Errorf("no CLIProxyAPI management URL configured")
+	}
+	files, err := c.management.authFiles(ctx, "codex", codexProxyPattern)
+	if err != nil {
+		return nil, err
+	}
 
+	accounts := make([]CodexAccount, 0, len(files))
+	for _, file := range files {
+		account, err := parseCodexCredentials(file.Data, file.Location, file.Name)
+		if err != nil {
+			sourceName := extractEmailFromFilename(file.Name)
+			account = CodexAccount{
+				Email:          sourceName,
+				SourceName:     sourceName,
+				CredentialPath: file.Location,
+				LoadErr:        err.Error(),
+			}
+		}
+		account.RefreshToken = ""
+		account.IsRemote = true
+		accounts = append(accounts, account)
+	}
 	return accounts, nil
 }
 
@@ -217,8 +430,13 @@ func (c *CodexProvider) loadNativeCredentials() ([]CodexAccount, error) {
 		}}, nil
 	}
 
+	accountID := creds.Tokens.AccountID
+	if accountID == "" {
+		accountID = codexTokenAccountID(creds.Tokens.AccessToken)
+	}
+
 	account := CodexAccount{
-		AccountID:      creds.Tokens.AccountID,
+		AccountID:      accountID,
 		Token:          creds.Tokens.AccessToken,
 		RefreshToken:   creds.Tokens.RefreshToken,
 		CredentialPath: path,
@@ -226,14 +444,7 @@ func (c *CodexProvider) loadNativeCredentials() ([]CodexAccount, error) {
 		DisplayName:    "native",
 	}
 
-	// Extract expiry from JWT
-	claims, err := decodeJWTClaims(creds.Tokens.AccessToken)
-	if err == nil {
-		if exp, ok := claims["exp"].(float64); ok {
-			account.ExpiresAt = time.Unix(int64(exp), 0)
-		}
-	}
-	// If JWT decode fails or exp missing, ExpiresAt stays at zero value
+	account.ExpiresAt = codexTokenExpiry(creds.Tokens.AccessToken)
 
 	// Parse last_refresh if present
 	if creds.LastRefresh != "" {
@@ -248,11 +459,13 @@ func (c *CodexProvider) loadNativeCredentials() ([]CodexAccount, error) {
 	return []CodexAccount{account}, nil
 }
 
+// applyCodexDisplayNames labels proxy accounts by email, telling apart
+// different ChatGPT accounts that share one. Native accounts keep "native".
 func applyCodexDisplayNames(accounts []CodexAccount) {
 	emailCounts := make(map[string]int)
 	emailHasAltSource := make(map[string]bool)
 	for _, account := range accounts {
-		if account.Email == "" {
+		if account.Email == "" || account.IsNative {
 			continue
 		}
 		key := strings.ToLower(account.Email)
@@ -263,6 +476,9 @@ func applyCodexDisplayNames(accounts []CodexAccount) {
 	}
 
 	for i := range accounts {
+		if accounts[i].IsNative {
+			continue
+		}
 		label := accounts[i].Email
 		if label == "" {
 			label = accounts[i].SourceName
@@ -286,13 +502,16 @@ func applyCodexDisplayNames(accounts []CodexAccount) {
 
 // loadCredentialFile loads a single credential file
 func (c *CodexProvider) loadCredentialFile(path string) (CodexAccount, error) {
-	filename := filepath.Base(path)
-	sourceName := extractEmailFromFilename(filename)
-
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return CodexAccount{}, fmt.Errorf("failed to read file: %w", err)
 	}
+	return parseCodexCredentials(data, path, filepath.Base(path))
+}
+
+// parseCodexCredentials parses a codex-*.json auth file named filename.
+func parseCodexCredentials(data []byte, path, filename string) (CodexAccount, error) {
+	sourceName := extractEmailFromFilename(filename)
 
 	var creds codexCredentials
 	if err := json.Unmarshal(data, &creds); err != nil {
@@ -307,14 +526,21 @@ func (c *CodexProvider) loadCredentialFile(path string) (CodexAccount, error) {
 	if email == "" {
 		email = sourceName
 	}
-	lastRefresh, _ := parseCodexTime(creds.LastRefresh)
-	expiresAt, _ := parseCodexTime(creds.Expired)
+	lastRefresh, _ := parseCredentialTime(creds.LastRefresh)
+	expiresAt, _ := parseCredentialTime(creds.Expired)
+	if expiresAt.IsZero() {
+		expiresAt = codexTokenExpiry(creds.AccessToken)
+	}
 
 	clientID, scopes := extractCodexAuthDetails(creds.AccessToken, creds.IDToken)
+	accountID := creds.AccountID
+	if accountID == "" {
+		accountID = codexTokenAccountID(creds.IDToken, creds.AccessToken)
+	}
 
 	return CodexAccount{
 		Email:          email,
-		AccountID:      creds.AccountID,
+		AccountID:      accountID,
 		SourceName:     sourceName,
 		Token:          creds.AccessToken,
 		IDToken:        creds.IDToken,
@@ -335,16 +561,6 @@ func extractEmailFromFilename(filename string) string {
 	return name
 }
 
-func parseCodexTime(value string) (time.Time, error) {
-	if value == "" {
-		return time.Time{}, nil
-	}
-	if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
-		return ts, nil
-	}
-	return time.Parse(time.RFC3339, value)
-}
-
 func shortID(value string) string {
 	if len(value) <= 6 {
 		return value
@@ -383,6 +599,26 @@ func (c *CodexProvider) fetchAccountUsage(ctx context.Context, account CodexAcco
 		return nil, fmt.Errorf("failed to load credentials")
 	}
 
+	// Refresh proxy tokens that are about to expire instead of waiting for a 401.
+	// The refresh token is rotated on use, so it is used at most once per run.
+	refreshed := false
+	if !account.IsNative && account.RefreshToken != "" && c.refresh.dueBefore(account.ExpiresAt, time.Now()) {
+		debugf("Codex", "token for %s expires at %s, refreshing before usage request", codexProviderName(account), account.ExpiresAt.Format(time.RFC3339))
+		token, refreshErr := c.refreshAccessToken(ctx, account)
+		switch {
+		case refreshErr == nil:
+			account.Token = token
+		case time.Now().Before(account.ExpiresAt):
+			// The current token is still valid, so a token endpoint outage
+			// should not hide usage until it actually expires.
+			debugf("Codex", "token refresh failed for %s, using current token until it expires: %v", codexProviderName(account), refreshErr)
+		default:
+			debugf("Codex", "token refresh failed for %s: %v", codexProviderName(account), refreshErr)
+			return nil, refreshErr
+		}
+		refreshed = true
+	}
+
 	apiResp, err := c.fetchUsageWithToken(ctx, account.Token)
 	if err != nil {
 		var statusErr APIStatusError
@@ -392,7 +628,7 @@ func (c *CodexProvider) fetchAccountUsage(ctx context.Context, account CodexAcco
 				return nil, fmt.Errorf("token expired. Re-authenticate with codex to refresh")
 			}
 			// For proxy credentials, attempt refresh if we have a refresh token
-			if account.RefreshToken != "" {
+			if account.RefreshToken != "" && !refreshed && !c.refresh.disabled {
 				debugf("Codex", "attempting token refresh after status=%d for %s", statusErr.StatusCode, codexProviderName(account))
 				refreshed, refreshErr := c.refreshAccessToken(ctx, account)
 				if refreshErr != nil {
@@ -412,22 +648,115 @@ func (c *CodexProvider) fetchAccountUsage(ctx context.Context, account CodexAcco
 	providerName := codexProviderName(account)
 	debugInfo := codexAccountDebug(account, apiResp.PlanType)
 
-	return []UsageRow{
-		{
-			Provider:     providerName,
-			Label:        "5-hour",
-			UsagePercent: apiResp.RateLimit.PrimaryWindow.UsedPercent,
-			ResetTime:    time.Unix(apiResp.RateLimit.PrimaryWindow.ResetAt, 0),
-			DebugInfo:    debugInfo,
-		},
-		{
+	now := time.Now()
+	var rows []UsageRow
+	rows = appendCodexWindowRows(rows, apiResp.RateLimit, providerName, "", debugInfo, now)
+	if review := apiResp.CodeReviewRateLimit; review != nil {
+		rows = appendCodexWindowRows(rows, *review, providerName, "code review", debugInfo, now)
+	}
+	if len(rows) == 0 {
+		rows = append(rows, UsageRow{
+			Provider:   providerName,
+			IsWarning:  true,
+			WarningMsg: "no usage windows reported",
+			DebugInfo:  debugInfo,
+		})
+	}
+
+	if apiResp.Credits != nil {
+		rows = append(rows, UsageRow{
+			Provider:  providerName,
+			Label:     "credits",
+			Credits:   codexCreditsFromAPI(apiResp.Credits),
+			DebugInfo: debugInfo,
+		})
+	}
+
+	setPlan(rows, apiResp.PlanType)
+	return rows, nil
+}
+
+func codexCreditsFromAPI(credits *codexCredits) *Credits {
+	out := &Credits{
+		HasCredits:          credits.HasCredits,
+		Unlimited:           credits.Unlimited,
+		ApproxLocalMessages: credits.ApproxLocalMessages,
+		ApproxCloudMessages: credits.ApproxCloudMessages,
+	}
+	if balance, err := strconv.ParseFloat(strings.TrimSpace(credits.Balance), 64); err == nil {
+		out.Balance = &balance
+	}
+	return out
+}
+
+// blocked reports whether the server says requests are currently rejected.
+func (l codexRateLimit) blocked() bool {
+	return l.LimitReached || (l.Allowed != nil && !*l.Allowed)
+}
+
+// appendCodexWindowRows adds a row for each non-null window of a rate limit.
+// Labels come from the server-reported window length, prefixed with kind
+// (e.g. "code review 7-day"); windows without a length are labelled by position.
+// When the limit is reached, full windows are marked blocked, or every window
+// if none is full.
+func appendCodexWindowRows(rows []UsageRow, limit codexRateLimit, providerName, kind, debugInfo string, now time.Time) []UsageRow {
+	windows := []struct {
+		position string
+		window   *codexWindow
+	}{
+		{"primary", limit.PrimaryWindow},
+		{"secondary", limit.SecondaryWindow},
+	}
+	anyFull := false
+	for _, w := range windows {
+		if w.window != nil && w.window.UsedPercent >= 100 {
+			anyFull = true
+		}
+	}
+	for _, w := range windows {
+		if w.window == nil {
+			continue
+		}
+		full := w.window.UsedPercent >= 100
+		label := WindowLabel(w.window.duration())
+		if label == "" {
+			label = w.position
+		}
+		rows = append(rows, UsageRow{
 			Provider:     providerName,
-			Label:        "7-day",
-			UsagePercent: apiResp.RateLimit.SecondaryWindow.UsedPercent,
-			ResetTime:    time.Unix(apiResp.RateLimit.SecondaryWindow.ResetAt, 0),
+			Label:        strings.TrimSpace(kind + " " + label),
+			UsagePercent: w.window.UsedPercent,
+			ResetTime:    w.window.resetTime(now),
+			Window:       w.window.duration(),
+			Blocked:      full || (limit.blocked() && !anyFull),
 			DebugInfo:    debugInfo,
-		},
-	}, nil
+		})
+	}
+	return rows
+}
+
+// codexAccountIdentity identifies the upstream ChatGPT account by its ID.
+func codexAccountIdentity(account CodexAccount) string {
+	return account.AccountID
+}
+
+func codexCredentialRank(account CodexAccount) credentialRank {
+	return credentialRank{
+		path:      account.CredentialPath,
+		failed:    account.LoadErr != "",
+		native:    account.IsNative,
+		expiresAt: account.ExpiresAt,
+	}
+}
+
+func codexAccountSource(account CodexAccount) CredentialSource {
+	if account.IsNative {
+		return SourceNative
+	}
+	if account.IsRemote {
+		return SourceManagement
+	}
+	return SourceProxy
 }
 
 func codexProviderName(account CodexAccount) string {
@@ -479,15 +808,83 @@ func (c *CodexProvider) fetchUsageWithToken(ctx context.Context, token string) (
 	return &apiResp, nil
 }
 
+// refreshAccessToken refreshes the access token in the credential file and
+// returns it.
 func (c *CodexProvider) refreshAccessToken(ctx context.Context, account CodexAccount) (string, error) {
+	token, _, err := c.refreshOrReuseAccessToken(ctx, account)
+	return token, err
+}
+
+// refreshOrReuseAccessToken is refreshAccessToken that also reports whether
+// another process had already refreshed the token, in which case the token
+// it wrote is returned without calling the token endpoint.
+func (c *CodexProvider) refreshOrReuseAccessToken(ctx context.Context, account CodexAccount) (string, bool, error) {
 	if account.IsNative {
-		return "", fmt.Errorf("token expired. Re-authenticate with codex to refresh")
+		return "", false, fmt.Errorf("token expired. Re-authenticate with codex to refresh")
+	}
+	if account.IsRemote {
+		return "", false, fmt.Errorf("token expired. CLIProxyAPI refreshes management API credentials")
 	}
 
 	if account.RefreshToken == "" {
-		return "", fmt.Errorf("refresh token not available")
+		return "", false, fmt.Errorf("refresh token not available")
 	}
+	if account.CredentialPath == "" {
+		return "", false, fmt.Errorf("credential path not available for refresh")
+	}
+
+	unlock, err := lockCredentialFile(ctx, account.CredentialPath)
+	if err != nil {
+		return "", false, err
+	}
+	defer unlock()
+
+	// CLIProxyAPI or another aim process may have rotated the tokens since they
+	// were loaded. Reuse a newer access token rather than spending the refresh
+	// token, and never refresh with one that has already been used.
+	if current, err := c.loadCredentialFile(account.CredentialPath); err == nil {
+		if current.Token != account.Token && !c.refresh.dueBefore(current.ExpiresAt, time.Now()) {
+			debugf("Codex", "credentials for %s were refreshed concurrently, reusing newer token", codexProviderName(account))
+			return current.Token, true, nil
+		}
+		if current.RefreshToken != "" {
+			account.RefreshToken = current.RefreshToken
+		}
+	}
+
+	raw, err := c.requestTokenRefresh(ctx, account.RefreshToken, account.ClientID, account.Scopes)
+	if isInvalidGrant(err) {
+		// CLIProxyAPI does not take aim's lock, so it may have spent the
+		// refresh token after it was read above. Pick up what it wrote.
+		if current, loadErr := c.loadCredentialFile(account.CredentialPath); loadErr == nil &&
+			current.RefreshToken != "" && current.RefreshToken != account.RefreshToken {
+			if current.Token != account.Token && !c.refresh.dueBefore(current.ExpiresAt, time.Now()) {
+				debugf("Codex", "refresh token for %s was rotated by another process, reusing newer token", codexProviderName(account))
+				return current.Token, true, nil
+			}
+			debugf("Codex", "refresh token for %s was rotated by another process, retrying with the newer one", codexProviderName(account))
+			raw, err = c.requestTokenRefresh(ctx, current.RefreshToken, account.ClientID, account.Scopes)
+		}
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	accessToken := stringFromMap(raw, "access_token", "accessToken", "token")
+	refreshToken := stringFromMap(raw, "refresh_token", "refreshToken")
+	idToken := stringFromMap(raw, "id_token", "idToken")
+	expiresIn := int64FromMap(raw, "expires_in", "expiresIn")
 
+	if err := updateCodexCredentialFile(account.CredentialPath, accessToken, refreshToken, idToken, expiresIn); err != nil {
+		return "", false, err
+	}
+
+	return accessToken, false, nil
+}
+
+// requestTokenRefresh exchanges refreshToken for new tokens, returning the
+// decoded response, which is guaranteed to hold an access token.
+func (c *CodexProvider) requestTokenRefresh(ctx context.Context, refreshToken, clientID string, scopes []string) (map[string]any, error) {
 	refreshURL := c.refreshURL
 	if refreshURL == "" {
 		refreshURL = codexRefreshURL
@@ -495,68 +892,52 @@ func (c *CodexProvider) refreshAccessToken(ctx context.Context, account CodexAcc
 
 	form := url.Values{}
 	form.Set("grant_type", "refresh_token")
-	form.Set("refresh_token", account.RefreshToken)
-	clientID := account.ClientID
+	form.Set("refresh_token", refreshToken)
 	if clientID == "" {
 		clientID = codexDefaultClientID
 	}
 	form.Set("client_id", clientID)
-	if len(account.Scopes) > 0 {
-		form.Set("scope", strings.Join(account.Scopes, " "))
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, refreshURL, strings.NewReader(form.Encode()))
 	if err != nil {
-		return "", fmt.Errorf("failed to create refresh request: %w", err)
+		return nil, fmt.Errorf("failed to create refresh request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
 		debugf("Codex", "token refresh request failed: %v", err)
-		return "", fmt.Errorf("token refresh request failed: %w", err)
+		return nil, fmt.Errorf("token refresh request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		debugf("Codex", "failed to read token refresh response: %v", err)
-		return "", fmt.Errorf("failed to read token refresh response: %w", err)
+		return nil, fmt.Errorf("failed to read token refresh response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		debugf("Codex", "token refresh non-200 status=%d body=%q", resp.StatusCode, debugBody(body))
-		return "", APIStatusError{
+		return nil, APIStatusError{
 			StatusCode: resp.StatusCode,
 			Body:       TruncateBody(body, 200),
 		}
 	}
 
-	var refreshResp struct {
-		Raw map[string]any
-	}
-	if err := json.Unmarshal(body, &refreshResp.Raw); err != nil {
+	var raw map[string]any
+	if err := json.Unmarshal(body, &raw); err != nil {
 		debugf("Codex", "failed to parse token refresh response: %v body=%q", err, debugBody(body))
-		return "", fmt.Errorf("failed to parse token refresh response: %w", err)
+		return nil, fmt.Errorf("failed to parse token refresh response: %w", err)
 	}
-	accessToken := stringFromMap(refreshResp.Raw, "access_token", "accessToken", "token")
-	if accessToken == "" {
+	if stringFromMap(raw, "access_token", "accessToken", "token") == "" {
 		debugf("Codex", "token refresh response missing access_token")
-		return "", fmt.Errorf("token refresh failed: empty access_token")
-	}
-
-	refreshToken := stringFromMap(refreshResp.Raw, "refresh_token", "refreshToken")
-	idToken := stringFromMap(refreshResp.Raw, "id_token", "idToken")
-	expiresIn := int64FromMap(refreshResp.Raw, "expires_in", "expiresIn")
-
-	if account.CredentialPath == "" {
-		return "", fmt.Errorf("credential path not available for refresh")
+		return nil, fmt.Errorf("token refresh failed: empty access_token")
 	}
-	if err := updateCodexCredentialFile(account.CredentialPath, accessToken, refreshToken, idToken, expiresIn); err != nil {
-		return "", err
-	}
-
-	return accessToken, nil
+	return raw, nil
 }
 
 func updateCodexCredentialFile(path, accessToken, refreshToken, idToken string, expiresIn int64) error {
@@ -631,6 +1012,35 @@ func extractCodexAuthDetails(accessToken, idToken string) (string, []string) {
 	return clientID, scopes
 }
 
+// codexTokenExpiry returns the exp claim of a JWT access token, or the zero
+// time if the token cannot be decoded or has no expiry.
+func codexTokenExpiry(token string) time.Time {
+	claims, err := decodeJWTClaims(token)
+	if err != nil {
+		return time.Time{}
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		return time.Unix(int64(exp), 0)
+	}
+	return time.Time{}
+}
+
+// codexTokenAccountID returns the ChatGPT account ID claim from the first of
+// tokens that carries one, or "" if none does.
+func codexTokenAccountID(tokens ...string) string {
+	for _, token := range tokens {
+		claims, err := decodeJWTClaims(token)
+		if err != nil {
+			continue
+		}
+		auth, _ := claims["https://api.openai.com/auth"].(map[string]any)
+		if id, ok := auth["chatgpt_account_id"].(string); ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
 // extractExplicitClientID extracts only the explicit client_id claim from a token,
 // without falling back to aud (which may contain API audience URLs).
 func extractExplicitClientID(token string) string {
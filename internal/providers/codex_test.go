@@ -2,7 +2,9 @@ package providers
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -62,10 +64,10 @@ func TestCodexProvider_FetchUsage_SingleAccount(t *testing.T) {
 		resp := codexAPIResponse{
 			PlanType: "pro",
 		}
-		resp.RateLimit.PrimaryWindow.UsedPercent = 25.5
-		resp.RateLimit.PrimaryWindow.ResetAt = resetTime
-		resp.RateLimit.SecondaryWindow.UsedPercent = 10.0
-		resp.RateLimit.SecondaryWindow.ResetAt = resetTime + 86400
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow:   &codexWindow{UsedPercent: 25.5, LimitWindowSeconds: 18000, ResetAt: resetTime},
+			SecondaryWindow: &codexWindow{UsedPercent: 10.0, LimitWindowSeconds: 604800, ResetAt: resetTime + 86400},
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(resp)
@@ -157,10 +159,10 @@ func TestCodexProvider_FetchUsage_MultipleAccounts(t *testing.T) {
 		resp := codexAPIResponse{
 			PlanType: "pro",
 		}
-		resp.RateLimit.PrimaryWindow.UsedPercent = data.primary
-		resp.RateLimit.PrimaryWindow.ResetAt = time.Now().Unix()
-		resp.RateLimit.SecondaryWindow.UsedPercent = data.secondary
-		resp.RateLimit.SecondaryWindow.ResetAt = time.Now().Unix()
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow:   &codexWindow{UsedPercent: data.primary, LimitWindowSeconds: 18000, ResetAt: time.Now().Unix()},
+			SecondaryWindow: &codexWindow{UsedPercent: data.secondary, LimitWindowSeconds: 604800, ResetAt: time.Now().Unix()},
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(resp)
@@ -263,10 +265,10 @@ func TestCodexProvider_FetchUsage_DuplicateEmailDisplaysBoth(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		callCount++
 		resp := codexAPIResponse{PlanType: "pro"}
-		resp.RateLimit.PrimaryWindow.UsedPercent = 10.0
-		resp.RateLimit.PrimaryWindow.ResetAt = resetTime
-		resp.RateLimit.SecondaryWindow.UsedPercent = 5.0
-		resp.RateLimit.SecondaryWindow.ResetAt = resetTime + 3600
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow:   &codexWindow{UsedPercent: 10.0, LimitWindowSeconds: 18000, ResetAt: resetTime},
+			SecondaryWindow: &codexWindow{UsedPercent: 5.0, LimitWindowSeconds: 604800, ResetAt: resetTime + 3600},
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(resp)
@@ -319,6 +321,60 @@ func TestCodexProvider_FetchUsage_DuplicateEmailDisplaysBoth(t *testing.T) {
 	}
 }
 
+func TestCodexProvider_FetchUsage_DuplicateAccountFetchedOnce(t *testing.T) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		resp := codexAPIResponse{PlanType: "pro"}
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow: &codexWindow{UsedPercent: 10.0, LimitWindowSeconds: 18000, ResetAt: time.Now().Add(time.Hour).Unix()},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	credDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	if err := os.MkdirAll(credDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stale copy names the account; the current one only carries it in its id_token.
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"https://api.openai.com/auth":{"chatgpt_account_id":"acct-1"}}`))
+	idToken := "eyJhbGciOiJub25lIn0." + claims + ".sig"
+	stale := fmt.Sprintf(`{"access_token":"token-stale","email":"user@example.com","account_id":"acct-1","expired":%q}`,
+		time.Now().Add(-24*time.Hour).Format(time.RFC3339))
+	current := fmt.Sprintf(`{"access_token":"token-current","id_token":%q,"email":"user@example.com","expired":%q}`,
+		idToken, time.Now().Add(24*time.Hour).Format(time.RFC3339))
+	if err := os.WriteFile(filepath.Join(credDir, "codex-user@example.com.json"), []byte(stale), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(credDir, "codex-user@example.com-backup.json"), []byte(current), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &CodexProvider{
+		homeDir: tmpDir,
+		baseURL: server.URL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+
+	if len(tokens) != 1 || tokens[0] != "token-current" {
+		t.Errorf("usage requests used tokens %v, want only token-current", tokens)
+	}
+	for _, row := range rows {
+		if row.Provider != "Codex (user@example.com)" {
+			t.Errorf("unexpected provider %q", row.Provider)
+		}
+	}
+}
+
 func TestCodexProvider_FetchUsage_NoCreds(t *testing.T) {
 	tmpDir := t.TempDir()
 	credDir := filepath.Join(tmpDir, ".cli-proxy-api")
@@ -352,10 +408,10 @@ func TestCodexProvider_FetchUsage_NoCreds(t *testing.T) {
 func TestCodexProvider_FetchUsage_MalformedFile(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := codexAPIResponse{PlanType: "pro"}
-		resp.RateLimit.PrimaryWindow.UsedPercent = 20
-		resp.RateLimit.PrimaryWindow.ResetAt = time.Now().Unix()
-		resp.RateLimit.SecondaryWindow.UsedPercent = 5
-		resp.RateLimit.SecondaryWindow.ResetAt = time.Now().Unix()
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow:   &codexWindow{UsedPercent: 20, LimitWindowSeconds: 18000, ResetAt: time.Now().Unix()},
+			SecondaryWindow: &codexWindow{UsedPercent: 5, LimitWindowSeconds: 604800, ResetAt: time.Now().Unix()},
+		}
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(resp)
 	}))
@@ -488,10 +544,10 @@ func TestCodexProvider_FetchUsage_RefreshesTokenOn401(t *testing.T) {
 		}
 
 		resp := codexAPIResponse{PlanType: "pro"}
-		resp.RateLimit.PrimaryWindow.UsedPercent = 12.0
-		resp.RateLimit.PrimaryWindow.ResetAt = time.Now().Unix()
-		resp.RateLimit.SecondaryWindow.UsedPercent = 34.0
-		resp.RateLimit.SecondaryWindow.ResetAt = time.Now().Unix()
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow:   &codexWindow{UsedPercent: 12.0, LimitWindowSeconds: 18000, ResetAt: time.Now().Unix()},
+			SecondaryWindow: &codexWindow{UsedPercent: 34.0, LimitWindowSeconds: 604800, ResetAt: time.Now().Unix()},
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(resp)
@@ -556,6 +612,295 @@ func TestCodexProvider_FetchUsage_RefreshesTokenOn401(t *testing.T) {
 	}
 }
 
+func TestCodexProvider_FetchUsage_RefreshesBeforeExpiry(t *testing.T) {
+	refreshCalls := 0
+	refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new-token","refresh_token":"new-refresh","expires_in":3600}`))
+	}))
+	defer refreshServer.Close()
+
+	var usageTokens []string
+	usageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		usageTokens = append(usageTokens, r.Header.Get("Authorization"))
+		resp := codexAPIResponse{PlanType: "plus"}
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow: &codexWindow{UsedPercent: 12.0, LimitWindowSeconds: 18000, ResetAt: time.Now().Unix()},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer usageServer.Close()
+
+	tmpDir := t.TempDir()
+	credDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	if err := os.MkdirAll(credDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	credFile := filepath.Join(credDir, "codex-user@example.com.json")
+	credData := fmt.Sprintf(`{"access_token": "old-token", "refresh_token": "refresh-token", "expired": %q}`,
+		time.Now().Add(-time.Minute).Format(time.RFC3339))
+	if err := os.WriteFile(credFile, []byte(credData), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &CodexProvider{
+		homeDir:    tmpDir,
+		baseURL:    usageServer.URL,
+		refreshURL: refreshServer.URL,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].IsWarning {
+		t.Fatalf("expected one usage row, got %+v", rows)
+	}
+	if refreshCalls != 1 {
+		t.Errorf("expected 1 refresh call, got %d", refreshCalls)
+	}
+	if len(usageTokens) != 1 || usageTokens[0] != "Bearer new-token" {
+		t.Errorf("expected a single usage call with the refreshed token, got %v", usageTokens)
+	}
+}
+
+func TestCodexProvider_FetchUsage_RefreshFailureKeepsValidToken(t *testing.T) {
+	refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer refreshServer.Close()
+
+	var usageTokens []string
+	usageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		usageTokens = append(usageTokens, r.Header.Get("Authorization"))
+		resp := codexAPIResponse{}
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow: &codexWindow{UsedPercent: 12.0, LimitWindowSeconds: 18000, ResetAt: time.Now().Unix()},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer usageServer.Close()
+
+	tests := []struct {
+		name      string
+		expired   time.Time
+		wantUsage bool
+	}{
+		{"still valid", time.Now().Add(time.Minute), true},
+		{"expired", time.Now().Add(-time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usageTokens = nil
+			tmpDir := t.TempDir()
+			credDir := filepath.Join(tmpDir, ".cli-proxy-api")
+			if err := os.MkdirAll(credDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			credData := fmt.Sprintf(`{"access_token": "old-token", "refresh_token": "refresh-token", "expired": %q}`,
+				tt.expired.Format(time.RFC3339))
+			if err := os.WriteFile(filepath.Join(credDir, "codex-user@example.com.json"), []byte(credData), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			provider := &CodexProvider{
+				homeDir:    tmpDir,
+				baseURL:    usageServer.URL,
+				refreshURL: refreshServer.URL,
+				client:     &http.Client{Timeout: 5 * time.Second},
+			}
+			rows, err := provider.FetchUsage(context.Background())
+			if err != nil {
+				t.Fatalf("FetchUsage() error = %v", err)
+			}
+			if len(rows) != 1 {
+				t.Fatalf("expected one row, got %+v", rows)
+			}
+			if tt.wantUsage {
+				if rows[0].IsWarning || len(usageTokens) != 1 || usageTokens[0] != "Bearer old-token" {
+					t.Errorf("expected usage fetched with the current token, got rows %+v tokens %v", rows, usageTokens)
+				}
+			} else if !rows[0].IsWarning || len(usageTokens) != 0 {
+				t.Errorf("expected refresh error for expired token, got rows %+v tokens %v", rows, usageTokens)
+			}
+		})
+	}
+}
+
+func TestCodexProvider_FetchUsage_NoRefresh(t *testing.T) {
+	refreshCalls := 0
+	refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new-token"}`))
+	}))
+	defer refreshServer.Close()
+
+	usageCalls := 0
+	usageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		usageCalls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer usageServer.Close()
+
+	tmpDir := t.TempDir()
+	credDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	if err := os.MkdirAll(credDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	credFile := filepath.Join(credDir, "codex-user@example.com.json")
+	credData := fmt.Sprintf(`{"access_token": "old-token", "refresh_token": "refresh-token", "expired": %q}`,
+		time.Now().Add(-time.Hour).Format(time.RFC3339))
+	if err := os.WriteFile(credFile, []byte(credData), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &CodexProvider{
+		homeDir:    tmpDir,
+		baseURL:    usageServer.URL,
+		refreshURL: refreshServer.URL,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		refresh:    refreshPolicy{disabled: true},
+	}
+
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || !rows[0].IsWarning {
+		t.Fatalf("expected a single warning row, got %+v", rows)
+	}
+	if refreshCalls != 0 {
+		t.Errorf("expected no refresh calls, got %d", refreshCalls)
+	}
+	if usageCalls != 1 {
+		t.Errorf("expected 1 usage call, got %d", usageCalls)
+	}
+	updated, err := os.ReadFile(credFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(updated) != credData {
+		t.Errorf("credentials file was rewritten: %s", updated)
+	}
+}
+
+func TestCodexRefreshAccessToken_UsesRotatedRefreshToken(t *testing.T) {
+	var usedRefreshTokens []string
+	refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		values, _ := url.ParseQuery(string(body))
+		usedRefreshTokens = append(usedRefreshTokens, values.Get("refresh_token"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new-token","refresh_token":"newer-refresh"}`))
+	}))
+	defer refreshServer.Close()
+
+	credFile := filepath.Join(t.TempDir(), "codex-user@example.com.json")
+	// The file still holds the same access token, but its refresh token was
+	// rotated by another process after aim loaded it.
+	if err := os.WriteFile(credFile, []byte(`{"access_token": "old-token", "refresh_token": "rotated-refresh"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	account := CodexAccount{
+		Token:          "old-token",
+		RefreshToken:   "stale-refresh",
+		CredentialPath: credFile,
+	}
+
+	provider := &CodexProvider{
+		refreshURL: refreshServer.URL,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+
+	token, err := provider.refreshAccessToken(context.Background(), account)
+	if err != nil {
+		t.Fatalf("refreshAccessToken() error = %v", err)
+	}
+	if token != "new-token" {
+		t.Errorf("token = %q, want %q", token, "new-token")
+	}
+	if len(usedRefreshTokens) != 1 || usedRefreshTokens[0] != "rotated-refresh" {
+		t.Errorf("refresh requests used %v, want [rotated-refresh]", usedRefreshTokens)
+	}
+}
+
+func TestCodexRefreshAccessToken_InvalidGrantPicksUpProxyRotation(t *testing.T) {
+	tests := []struct {
+		name          string
+		proxyCreds    string
+		wantToken     string
+		wantRefreshes []string
+	}{
+		{
+			name:          "reuses proxy access token",
+			proxyCreds:    `{"access_token": "proxy-token", "refresh_token": "proxy-refresh"}`,
+			wantToken:     "proxy-token",
+			wantRefreshes: []string{"old-refresh"},
+		},
+		{
+			name:          "retries with proxy refresh token",
+			proxyCreds:    `{"access_token": "old-token", "refresh_token": "proxy-refresh"}`,
+			wantToken:     "new-token",
+			wantRefreshes: []string{"old-refresh", "proxy-refresh"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credFile := filepath.Join(t.TempDir(), "codex-user@example.com.json")
+			if err := os.WriteFile(credFile, []byte(`{"access_token": "old-token", "refresh_token": "old-refresh"}`), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			var usedRefreshTokens []string
+			refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				values, _ := url.ParseQuery(string(body))
+				usedRefreshTokens = append(usedRefreshTokens, values.Get("refresh_token"))
+				if values.Get("refresh_token") == "old-refresh" {
+					// CLIProxyAPI spent the token while aim's request was in flight.
+					if err := os.WriteFile(credFile, []byte(tt.proxyCreds), 0600); err != nil {
+						t.Error(err)
+					}
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"access_token":"new-token","refresh_token":"newer-refresh"}`))
+			}))
+			defer refreshServer.Close()
+
+			account := CodexAccount{
+				Token:          "old-token",
+				RefreshToken:   "old-refresh",
+				CredentialPath: credFile,
+			}
+			provider := &CodexProvider{
+				refreshURL: refreshServer.URL,
+				client:     &http.Client{Timeout: 5 * time.Second},
+			}
+
+			token, err := provider.refreshAccessToken(context.Background(), account)
+			if err != nil {
+				t.Fatalf("refreshAccessToken() error = %v", err)
+			}
+			if token != tt.wantToken {
+				t.Errorf("token = %q, want %q", token, tt.wantToken)
+			}
+			if strings.Join(usedRefreshTokens, ",") != strings.Join(tt.wantRefreshes, ",") {
+				t.Errorf("refresh requests used %v, want %v", usedRefreshTokens, tt.wantRefreshes)
+			}
+		})
+	}
+}
+
 func TestCodexProvider_FetchUsage_MissingAccessToken(t *testing.T) {
 	tmpDir := t.TempDir()
 	credDir := filepath.Join(tmpDir, ".cli-proxy-api")
@@ -810,7 +1155,7 @@ func TestCodexLoadNativeCredentials_MissingAccessToken(t *testing.T) {
 }
 
 func TestCodexLoadCredentials_UsesGlobalSource(t *testing.T) {
-	// Test that loadCredentials respects DetectCredentialSource
+	// Test that loadCredentials falls back to native without Codex proxy files
 	tmpDir := t.TempDir()
 
 	// Create native credentials (no proxy dir)
@@ -886,6 +1231,33 @@ func TestCodexLoadCredentials_PrefersProxy(t *testing.T) {
 	}
 }
 
+func TestCodexLoadCredentials_MultipleProxyDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	firstDir := filepath.Join(tmpDir, "first")
+	secondDir := filepath.Join(tmpDir, "second")
+	for dir, email := range map[string]string{firstDir: "first@example.com", secondDir: "second@example.com"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		data := `{"access_token": "token", "email": "` + email + `"}`
+		if err := os.WriteFile(filepath.Join(dir, "codex-"+email+".json"), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := &CodexProvider{homeDir: tmpDir, proxyDirs: []string{firstDir, secondDir}}
+	accounts, err := provider.loadCredentials()
+	if err != nil {
+		t.Fatalf("loadCredentials() error = %v", err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("got %d accounts, want 2", len(accounts))
+	}
+	if accounts[0].Email != "first@example.com" || accounts[1].Email != "second@example.com" {
+		t.Errorf("expected accounts in directory order, got %q and %q", accounts[0].Email, accounts[1].Email)
+	}
+}
+
 func TestCodexRefreshAccessToken_NativeSkip(t *testing.T) {
 	provider := &CodexProvider{}
 	account := CodexAccount{
@@ -901,3 +1273,218 @@ func TestCodexRefreshAccessToken_NativeSkip(t *testing.T) {
 		t.Errorf("error = %q, want message about re-authentication", err.Error())
 	}
 }
+
+func TestCodexProvider_FetchUsage_CodeReviewAndCredits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"plan_type": "pro",
+			"rate_limit": {
+				"primary_window": {"used_percent": 3, "limit_window_seconds": 18000, "reset_at": 1767385852},
+				"secondary_window": {"used_percent": 9, "limit_window_seconds": 604800, "reset_at": 1767859444}
+			},
+			"code_review_rate_limit": {
+				"allowed": true,
+				"limit_reached": false,
+				"primary_window": {"used_percent": 40, "limit_window_seconds": 604800, "reset_at": 1767974794},
+				"secondary_window": null
+			},
+			"credits": {
+				"has_credits": true,
+				"unlimited": false,
+				"balance": "12.5",
+				"approx_local_messages": [10, 20],
+				"approx_cloud_messages": [2, 4]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	credDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	if err := os.MkdirAll(credDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	credData := `{"access_token": "test-token"}`
+	if err := os.WriteFile(filepath.Join(credDir, "codex-user@example.com.json"), []byte(credData), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &CodexProvider{
+		homeDir: tmpDir,
+		baseURL: server.URL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("FetchUsage() returned %d rows, want 4: %+v", len(rows), rows)
+	}
+
+	for i, row := range rows {
+		if row.Plan != "pro" {
+			t.Errorf("rows[%d].Plan = %q, want %q", i, row.Plan, "pro")
+		}
+	}
+
+	review := rows[2]
+	if review.Label != "code review 7-day" || review.UsagePercent != 40 {
+		t.Errorf("code review row = %q %.0f%%, want \"code review 7-day\" 40%%", review.Label, review.UsagePercent)
+	}
+	if !review.ResetTime.Equal(time.Unix(1767974794, 0)) {
+		t.Errorf("code review ResetTime = %v", review.ResetTime)
+	}
+
+	credits := rows[3]
+	if credits.Label != "credits" || credits.Credits == nil {
+		t.Fatalf("credits row = %+v", credits)
+	}
+	if credits.HasUsagePercent() {
+		t.Error("credits row should not report a usage percent")
+	}
+	if !credits.Credits.HasCredits || credits.Credits.Unlimited {
+		t.Errorf("credits flags = %+v", credits.Credits)
+	}
+	if credits.Credits.Balance == nil || *credits.Credits.Balance != 12.5 {
+		t.Errorf("credits balance = %v, want 12.5", credits.Credits.Balance)
+	}
+	if len(credits.Credits.ApproxLocalMessages) != 2 || credits.Credits.ApproxCloudMessages[1] != 4 {
+		t.Errorf("approx messages = %v / %v", credits.Credits.ApproxLocalMessages, credits.Credits.ApproxCloudMessages)
+	}
+}
+
+func TestCodexProvider_FetchUsage_WindowDurations(t *testing.T) {
+	tests := []struct {
+		name       string
+		rateLimit  string
+		wantLabels []string
+		wantWindow []time.Duration
+		wantWarn   bool
+	}{
+		{
+			name: "server window lengths",
+			rateLimit: `{"primary_window": {"used_percent": 3, "limit_window_seconds": 10800, "reset_at": 1767385852},
+				"secondary_window": {"used_percent": 9, "limit_window_seconds": 2592000, "reset_at": 1767859444}}`,
+			wantLabels: []string{"3-hour", "30-day"},
+			wantWindow: []time.Duration{3 * time.Hour, 30 * 24 * time.Hour},
+		},
+		{
+			name:       "null secondary window is skipped",
+			rateLimit:  `{"primary_window": {"used_percent": 3, "limit_window_seconds": 18000, "reset_after_seconds": 600}, "secondary_window": null}`,
+			wantLabels: []string{"5-hour"},
+			wantWindow: []time.Duration{5 * time.Hour},
+		},
+		{
+			name:       "missing window length falls back to position",
+			rateLimit:  `{"primary_window": {"used_percent": 3, "reset_at": 1767385852}}`,
+			wantLabels: []string{"primary"},
+			wantWindow: []time.Duration{0},
+		},
+		{
+			name:      "no windows",
+			rateLimit: `{"primary_window": null, "secondary_window": null}`,
+			wantWarn:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"plan_type": "pro", "rate_limit": ` + tt.rateLimit + `}`))
+			}))
+			defer server.Close()
+
+			tmpDir := t.TempDir()
+			credDir := filepath.Join(tmpDir, ".cli-proxy-api")
+			if err := os.MkdirAll(credDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(credDir, "codex-a@example.com.json"), []byte(`{"access_token": "t"}`), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			provider := &CodexProvider{
+				homeDir: tmpDir,
+				baseURL: server.URL,
+				client:  &http.Client{Timeout: 5 * time.Second},
+			}
+			rows, err := provider.FetchUsage(context.Background())
+			if err != nil {
+				t.Fatalf("FetchUsage() error = %v", err)
+			}
+
+			if tt.wantWarn {
+				if len(rows) != 1 || !rows[0].IsWarning {
+					t.Fatalf("expected a single warning row, got %+v", rows)
+				}
+				return
+			}
+			if len(rows) != len(tt.wantLabels) {
+				t.Fatalf("got %d rows, want %d: %+v", len(rows), len(tt.wantLabels), rows)
+			}
+			for i, row := range rows {
+				if row.Label != tt.wantLabels[i] || row.Window != tt.wantWindow[i] {
+					t.Errorf("row[%d] = %q (%v), want %q (%v)", i, row.Label, row.Window, tt.wantLabels[i], tt.wantWindow[i])
+				}
+				if row.ResetTime.IsZero() || row.ResetTime.Unix() == 0 {
+					t.Errorf("row[%d].ResetTime = %v, want a real reset time", i, row.ResetTime)
+				}
+			}
+		})
+	}
+}
+
+func TestAppendCodexWindowRows_Blocked(t *testing.T) {
+	allowed, denied := true, false
+	tests := []struct {
+		name  string
+		limit codexRateLimit
+		want  []bool
+	}{
+		{
+			name: "allowed",
+			limit: codexRateLimit{
+				Allowed:         &allowed,
+				PrimaryWindow:   &codexWindow{UsedPercent: 97, LimitWindowSeconds: 18000},
+				SecondaryWindow: &codexWindow{UsedPercent: 40, LimitWindowSeconds: 604800},
+			},
+			want: []bool{false, false},
+		},
+		{
+			name: "not allowed below 100 blocks every window",
+			limit: codexRateLimit{
+				Allowed:         &denied,
+				PrimaryWindow:   &codexWindow{UsedPercent: 97, LimitWindowSeconds: 18000},
+				SecondaryWindow: &codexWindow{UsedPercent: 40, LimitWindowSeconds: 604800},
+			},
+			want: []bool{true, true},
+		},
+		{
+			name: "limit reached blocks the full window",
+			limit: codexRateLimit{
+				LimitReached:    true,
+				PrimaryWindow:   &codexWindow{UsedPercent: 100, LimitWindowSeconds: 18000},
+				SecondaryWindow: &codexWindow{UsedPercent: 40, LimitWindowSeconds: 604800},
+			},
+			want: []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := appendCodexWindowRows(nil, tt.limit, "Codex (a)", "", "", time.Now())
+			if len(rows) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, row := range rows {
+				if row.Blocked != tt.want[i] {
+					t.Errorf("row[%d] %s Blocked = %v, want %v", i, row.Label, row.Blocked, tt.want[i])
+				}
+			}
+		})
+	}
+}
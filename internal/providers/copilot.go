@@ -0,0 +1,565 @@
+package providers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	copilotDefaultBaseURL = "https://api.github.com"
+	copilotProxyPattern   = "github-copilot-*.json"
+)
+
+// CopilotAccount holds the GitHub OAuth token of a single Copilot account.
+// GitHub OAuth tokens do not expire, so there is nothing to refresh.
+type CopilotAccount struct {
+	User           string
+	Token          string
+	CredentialPath string
+	LoadErr        string // Error message from loading credentials, if any
+	IsNative       bool   // true when loaded from ~/.config/github-copilot/ instead of proxy
+	IsRemote       bool   // true when loaded from the management API
+}
+
+// copilotCredentials represents the JSON structure of CLIProxyAPI
+// github-copilot-*.json files
+type copilotCredentials struct {
+	AccessToken string `json:"access_token"`
+	OAuthToken  string `json:"oauth_token"`
+	Username    string `json:"username"`
+	Login       string `json:"login"`
+	Email       string `json:"email"`
+	Type        string `json:"type"`
+}
+
+// copilotApp is one entry of ~/.config/github-copilot/apps.json or
+// hosts.json, keyed by "host:app_id" or "host".
+type copilotApp struct {
+	User       string `json:"user"`
+	OAuthToken string `json:"oauth_token"`
+}
+
+// copilotUserResponse represents the copilot_internal/user API response.
+// Paid plans report quota_snapshots; the free plan reports remaining counts
+// in limited_user_quotas out of monthly_quotas.
+type copilotUserResponse struct {
+	Login                string                     `json:"login"`
+	CopilotPlan          string                     `json:"copilot_plan"`
+	QuotaResetDate       string                     `json:"quota_reset_date"`
+	QuotaSnapshots       map[string]copilotSnapshot `json:"quota_snapshots"`
+	LimitedUserQuotas    map[string]float64         `json:"limited_user_quotas"`
+	MonthlyQuotas        map[string]float64         `json:"monthly_quotas"`
+	LimitedUserResetDate string                     `json:"limited_user_reset_date"`
+}
+
+type copilotSnapshot struct {
+	Entitlement      float64 `json:"entitlement"`
+	Remaining        float64 `json:"remaining"`
+	PercentRemaining float64 `json:"percent_remaining"`
+	Unlimited        bool    `json:"unlimited"`
+	OverageCount     float64 `json:"overage_count"`
+	OveragePermitted bool    `json:"overage_permitted"`
+}
+
+// copilotQuotaLabels orders the quotas shown and names their rows.
+var copilotQuotaLabels = []struct {
+	id    string
+	label string
+}{
+	{"premium_interactions", "premium requests"},
+	{"chat", "chat"},
+	{"completions", "completions"},
+}
+
+// CopilotProvider implements the Provider interface for GitHub Copilot
+type CopilotProvider struct {
+	homeDir    string
+	proxyDirs  []string
+	baseURL    string
+	client     *http.Client
+	source     SourceMode
+	management *managementClient
+}
+
+// CopilotName is the name Copilot is registered under and the prefix of its rows.
+const CopilotName = "Copilot"
+
+func init() {
+	Register(Registration{
+		Name:  CopilotName,
+		Order: 4,
+		New:   func(opts Options) (Provider, error) { return NewCopilotProviderWithOptions(opts) },
+	})
+}
+
+// NewCopilotProvider creates a new CopilotProvider with default settings
+func NewCopilotProvider() (*CopilotProvider, error) {
+	return NewCopilotProviderWithOptions(Options{})
+}
+
+// NewCopilotProviderWithOptions creates a new CopilotProvider with the given options
+func NewCopilotProviderWithOptions(opts Options) (*CopilotProvider, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	timeout := 30 * time.Second
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+	}
+	return &CopilotProvider{
+		homeDir:    homeDir,
+		proxyDirs:  opts.CredentialsDirs,
+		baseURL:    copilotDefaultBaseURL,
+		client:     client,
+		source:     opts.Source,
+		management: newManagementClient(opts, client),
+	}, nil
+}
+
+// Name returns the provider name
+func (c *CopilotProvider) Name() string {
+	return CopilotName
+}
+
+// FetchUsage fetches the monthly quotas of every configured Copilot account
+func (c *CopilotProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
+	accounts, err := c.loadCredentialsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accounts) == 0 {
+		locations := credentialLocations(c.CredentialSources(), c.credentialDirs(), copilotProxyPattern, c.management,
+			"~/.config/github-copilot/apps.json")
+		return []UsageRow{{
+			Provider:   CopilotName,
+			IsWarning:  true,
+			WarningMsg: "No credentials found in " + locations,
+		}}, nil
+	}
+
+	var rows []UsageRow
+	for _, account := range accounts {
+		accountRows, err := c.fetchAccountUsage(ctx, account)
+		if err != nil {
+			accountRows = []UsageRow{{
+				Provider:   copilotProviderName(account),
+				IsWarning:  true,
+				WarningMsg: err.Error(),
+			}}
+		}
+		setSource(accountRows, copilotAccountSource(account))
+		rows = append(rows, accountRows...)
+	}
+	return rows, nil
+}
+
+// DiagnoseCredentials reports on every Copilot credential file, proxy and native.
+func (c *CopilotProvider) DiagnoseCredentials() []CredentialReport {
+	active := c.CredentialSources()
+	using := sourceNames(active, c.proxyDirs, c.management)
+	var reports []CredentialReport
+	var accounts []CopilotAccount
+	for _, path := range globCredentialFiles(c.credentialDirs(), copilotProxyPattern) {
+		report, ok := newCredentialReport(c.Name(), path, SourceProxy, active, using)
+		if !ok {
+			continue
+		}
+		if report.Err == "" {
+			if account, err := c.loadCredentialFile(path); err != nil {
+				report.Err = err.Error()
+			} else {
+				report.setLoaded("github-copilot", account.User, time.Time{}, false)
+				if report.Loaded() {
+					accounts = append(accounts, account)
+				}
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	if c.homeDir != "" {
+		for _, path := range c.nativePaths() {
+			report, ok := newCredentialReport(c.Name(), path, SourceNative, active, using)
+			if !ok {
+				continue
+			}
+			if report.Err == "" {
+				if native, err := loadCopilotApps(path); err != nil {
+					report.Err = err.Error()
+				} else {
+					users := make([]string, 0, len(native))
+					for _, account := range native {
+						users = append(users, account.User)
+					}
+					report.setLoaded("github-copilot", strings.Join(users, ", "), time.Time{}, false)
+					if report.Loaded() {
+						accounts = append(accounts, native...)
+					}
+				}
+			}
+			reports = append(reports, report)
+		}
+	}
+
+	markDuplicates(reports, duplicateCredentials(accounts, copilotAccountIdentity, copilotCredentialRank))
+	return reports
+}
+
+// credentialDirs returns the CLIProxyAPI credential directories for this provider.
+func (c *CopilotProvider) credentialDirs() []string {
+	return ProxyCredentialDirs(c.homeDir, c.proxyDirs)
+}
+
+// CredentialSources returns the credential sources loaded under the
+// configured source mode, proxy first.
+func (c *CopilotProvider) CredentialSources() []CredentialSource {
+	return c.source.sources(c.credentialDirs(), copilotProxyPattern, c.management != nil)
+}
+
+// loadCredentials is loadCredentialsContext for callers without a context.
+func (c *CopilotProvider) loadCredentials() ([]CopilotAccount, error) {
+	return c.loadCredentialsContext(context.Background())
+}
+
+// loadCredentialsContext loads accounts from every selected credential source,
+// keeping one token per GitHub user when several files hold one.
+func (c *CopilotProvider) loadCredentialsContext(ctx context.Context) ([]CopilotAccount, error) {
+	var accounts []CopilotAccount
+	for _, source := range c.CredentialSources() {
+		switch source {
+		case SourceNative:
+			accounts = append(accounts, c.loadNativeCredentials()...)
+		case SourceManagement:
+			loaded, err := c.loadManagementCredentials(ctx)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, loaded...)
+		default:
+			accounts = append(accounts, c.loadProxyCredentials()...)
+		}
+	}
+	return dedupeAccounts(c.Name(), accounts, copilotAccountIdentity, copilotCredentialRank), nil
+}
+
+// loadProxyCredentials loads every github-copilot-*.json file in the proxy directories.
+func (c *CopilotProvider) loadProxyCredentials() []CopilotAccount {
+	matches := globCredentialFiles(c.credentialDirs(), copilotProxyPattern)
+
+	accounts := make([]CopilotAccount, 0, len(matches))
+	for _, path := range matches {
+		account, err := c.loadCredentialFile(path)
+		if err != nil {
+			account = CopilotAccount{
+				User:           copilotUserFromFilename(filepath.Base(path)),
+				CredentialPath: path,
+				LoadErr:        err.Error(),
+			}
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+// loadManagementCredentials loads github-copilot auth files from the
+// CLIProxyAPI management API.
+func (c *CopilotProvider) loadManagementCredentials(ctx context.Context) ([]CopilotAccount, error) {
+	if c.management == nil {
+		return nil, fmt.Errorf("no CLIProxyAPI management URL configured")
+	}
+	files, err := c.management.authFiles(ctx, "github-copilot", copilotProxyPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]CopilotAccount, 0, len(files))
+	for _, file := range files {
+		account, err := parseCopilotCredentials(file.Data, file.Location, file.Name)
+		if err != nil {
+			account = CopilotAccount{
+				User:           copilotUserFromFilename(file.Name),
+				CredentialPath: file.Location,
+				LoadErr:        err.Error(),
+			}
+		}
+		account.IsRemote = true
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// nativePaths returns the files where Copilot editors and CLIs store their
+// GitHub token: apps.json, and hosts.json from older releases.
+func (c *CopilotProvider) nativePaths() []string {
+	dir := filepath.Join(c.homeDir, ".config", "github-copilot")
+	return []string{filepath.Join(dir, "apps.json"), filepath.Join(dir, "hosts.json")}
+}
+
+// loadNativeCredentials loads the github.com tokens in the native Copilot
+// config files. Missing files yield no accounts; unparsable ones yield an
+// account with LoadErr set.
+func (c *CopilotProvider) loadNativeCredentials() []CopilotAccount {
+	if c.homeDir == "" {
+		return nil
+	}
+
+	var accounts []CopilotAccount
+	for _, path := range c.nativePaths() {
+		loaded, err := loadCopilotApps(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			accounts = append(accounts, CopilotAccount{CredentialPath: path, IsNative: true, LoadErr: err.Error()})
+			continue
+		}
+		accounts = append(accounts, loaded...)
+	}
+	return accounts
+}
+
+// loadCopilotApps parses an apps.json or hosts.json file, returning one
+// account per github.com entry with a token, ordered by key.
+func loadCopilotApps(path string) ([]CopilotAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var apps map[string]copilotApp
+	if err := json.Unmarshal(data, &apps); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	keys := make([]string, 0, len(apps))
+	for key := range apps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var accounts []CopilotAccount
+	for _, key := range keys {
+		host, _, _ := strings.Cut(key, ":")
+		if host != "github.com" {
+			debugf(CopilotName, "skipping %s entry %q: only github.com is supported", path, key)
+			continue
+		}
+		if apps[key].OAuthToken == "" {
+			continue
+		}
+		accounts = append(accounts, CopilotAccount{
+			User:           apps[key].User,
+			Token:          apps[key].OAuthToken,
+			CredentialPath: path,
+			IsNative:       true,
+		})
+	}
+	return accounts, nil
+}
+
+// loadCredentialFile loads a single github-copilot-*.json credential file
+func (c *CopilotProvider) loadCredentialFile(path string) (CopilotAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CopilotAccount{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	return parseCopilotCredentials(data, path, filepath.Base(path))
+}
+
+// parseCopilotCredentials parses a github-copilot-*.json auth file named filename.
+func parseCopilotCredentials(data []byte, path, filename string) (CopilotAccount, error) {
+	var creds copilotCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return CopilotAccount{}, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if creds.Type != "" && creds.Type != "github-copilot" {
+		return CopilotAccount{}, fmt.Errorf("credential type %q is not github-copilot", creds.Type)
+	}
+	token := creds.AccessToken
+	if token == "" {
+		token = creds.OAuthToken
+	}
+	if token == "" {
+		return CopilotAccount{}, fmt.Errorf("missing access_token")
+	}
+
+	user := cmp.Or(creds.Username, creds.Login, creds.Email, copilotUserFromFilename(filename))
+	return CopilotAccount{
+		User:           user,
+		Token:          token,
+		CredentialPath: path,
+	}, nil
+}
+
+// copilotUserFromFilename extracts the user from a name like "github-copilot-octocat.json"
+func copilotUserFromFilename(filename string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(filename, "github-copilot-"), ".json")
+}
+
+// fetchAccountUsage fetches the quotas for a single account
+func (c *CopilotProvider) fetchAccountUsage(ctx context.Context, account CopilotAccount) ([]UsageRow, error) {
+	if account.Token == "" {
+		if account.LoadErr != "" {
+			return nil, fmt.Errorf("failed to load credentials: %s", account.LoadErr)
+		}
+		return nil, fmt.Errorf("failed to load credentials")
+	}
+
+	apiResp, err := c.fetchUser(ctx, account.Token)
+	if err != nil {
+		return nil, err
+	}
+	if account.User == "" {
+		account.User = apiResp.Login
+	}
+	rows := copilotQuotaRows(apiResp, copilotProviderName(account))
+	if len(rows) == 0 {
+		rows = append(rows, UsageRow{
+			Provider:   copilotProviderName(account),
+			IsWarning:  true,
+			WarningMsg: "no limited quotas reported",
+		})
+	}
+	setPlan(rows, apiResp.CopilotPlan)
+	return rows, nil
+}
+
+// copilotQuotaRows builds a row for each limited quota. Unlimited
+// entitlements have nothing to track and are omitted.
+func copilotQuotaRows(apiResp *copilotUserResponse, providerName string) []UsageRow {
+	resetDate := apiResp.QuotaResetDate
+	if resetDate == "" {
+		resetDate = apiResp.LimitedUserResetDate
+	}
+	resetTime := parseCopilotResetDate(resetDate)
+
+	var rows []UsageRow
+	for _, quota := range copilotQuotaLabels {
+		row := UsageRow{
+			Provider:  providerName,
+			Label:     quota.label,
+			ResetTime: resetTime,
+		}
+		if snapshot, ok := apiResp.QuotaSnapshots[quota.id]; ok {
+			if snapshot.Unlimited || snapshot.Entitlement <= 0 {
+				continue
+			}
+			row.UsagePercent = min(100, max(0, 100-snapshot.PercentRemaining))
+			row.Blocked = snapshot.Remaining <= 0 && !snapshot.OveragePermitted
+			row.DebugInfo = fmt.Sprintf("used:%g/%g", snapshot.Entitlement-snapshot.Remaining, snapshot.Entitlement)
+			if snapshot.OverageCount > 0 {
+				row.DebugInfo += fmt.Sprintf(" overage:%g", snapshot.OverageCount)
+			}
+		} else if total := apiResp.MonthlyQuotas[quota.id]; total > 0 {
+			remaining, ok := apiResp.LimitedUserQuotas[quota.id]
+			if !ok {
+				continue
+			}
+			row.UsagePercent = min(100, max(0, (total-remaining)/total*100))
+			row.Blocked = remaining <= 0
+			row.DebugInfo = fmt.Sprintf("used:%g/%g", total-remaining, total)
+		} else {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// parseCopilotResetDate parses a reset date such as "2025-08-01", which
+// GitHub reports as the UTC day the monthly quotas reset. RFC 3339
+// timestamps are accepted too.
+func parseCopilotResetDate(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t
+	}
+	t, _ := parseCredentialTime(value)
+	return t
+}
+
+func (c *CopilotProvider) fetchUser(ctx context.Context, token string) (*copilotUserResponse, error) {
+	baseURL := c.baseURL
+	if baseURL == "" {
+		baseURL = copilotDefaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/copilot_internal/user", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", UserAgent())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		debugf(CopilotName, "user API non-200 status=%d body=%q", resp.StatusCode, debugBody(body))
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("GitHub token rejected. Sign in to Copilot again")
+		}
+		return nil, APIStatusError{
+			StatusCode: resp.StatusCode,
+			Body:       TruncateBody(body, 200),
+		}
+	}
+
+	var apiResp copilotUserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &apiResp, nil
+}
+
+// copilotAccountIdentity identifies the upstream account by GitHub user.
+func copilotAccountIdentity(account CopilotAccount) string {
+	return strings.ToLower(account.User)
+}
+
+func copilotCredentialRank(account CopilotAccount) credentialRank {
+	return credentialRank{
+		path:   account.CredentialPath,
+		failed: account.LoadErr != "",
+		native: account.IsNative,
+		legacy: account.IsNative && filepath.Base(account.CredentialPath) == "hosts.json",
+	}
+}
+
+func copilotAccountSource(account CopilotAccount) CredentialSource {
+	if account.IsNative {
+		return SourceNative
+	}
+	if account.IsRemote {
+		return SourceManagement
+	}
+	return SourceProxy
+}
+
+func copilotProviderName(account CopilotAccount) string {
+	if account.User == "" {
+		return CopilotName
+	}
+	return fmt.Sprintf("%s (%s)", CopilotName, account.User)
+}
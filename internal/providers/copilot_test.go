@@ -0,0 +1,183 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCopilotApps writes a native ~/.config/github-copilot/apps.json.
+func writeCopilotApps(t *testing.T, homeDir, contents string) {
+	t.Helper()
+	dir := filepath.Join(homeDir, ".config", "github-copilot")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "apps.json"), []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newCopilotServer(t *testing.T, token, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/copilot_internal/user" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "token "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCopilotProvider_Name(t *testing.T) {
+	provider := &CopilotProvider{}
+	if got := provider.Name(); got != "Copilot" {
+		t.Errorf("Name() = %q, want %q", got, "Copilot")
+	}
+}
+
+func TestCopilotProvider_FetchUsage_PremiumRequests(t *testing.T) {
+	server := newCopilotServer(t, "gho_native", `{
+		"login": "octocat",
+		"copilot_plan": "individual",
+		"quota_reset_date": "2030-02-01",
+		"quota_snapshots": {
+			"chat": {"entitlement": 0, "remaining": 0, "percent_remaining": 100, "unlimited": true},
+			"completions": {"entitlement": 0, "remaining": 0, "percent_remaining": 100, "unlimited": true},
+			"premium_interactions": {"entitlement": 300, "remaining": 75, "percent_remaining": 25, "unlimited": false, "overage_permitted": false}
+		}
+	}`)
+
+	homeDir := t.TempDir()
+	writeCopilotApps(t, homeDir, `{
+		"github.com:Iv1.b507a08c87ecfe98": {"user": "octocat", "oauth_token": "gho_native", "githubAppId": "Iv1.b507a08c87ecfe98"},
+		"github.com:Iv23ctfURkiMfJ4xr5mv": {"user": "Octocat", "oauth_token": "gho_native", "githubAppId": "Iv23ctfURkiMfJ4xr5mv"},
+		"ghe.example.com:Iv1.b507a08c87ecfe98": {"user": "octocat", "oauth_token": "ghu_enterprise"}
+	}`)
+
+	provider := &CopilotProvider{homeDir: homeDir, baseURL: server.URL, client: server.Client()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected only the limited premium request row, got %+v", rows)
+	}
+	row := rows[0]
+	if row.Provider != "Copilot (octocat)" || row.Label != "premium requests" || row.Plan != "individual" || row.Source != "native" {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if row.UsagePercent != 75 || row.Blocked {
+		t.Errorf("UsagePercent = %v, Blocked = %v, want 75 and false", row.UsagePercent, row.Blocked)
+	}
+	if !row.ResetTime.Equal(time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ResetTime = %v, want 2030-02-01", row.ResetTime)
+	}
+}
+
+func TestCopilotProvider_FetchUsage_FreePlan(t *testing.T) {
+	server := newCopilotServer(t, "gho_free", `{
+		"login": "octocat",
+		"copilot_plan": "free",
+		"limited_user_reset_date": "2030-02-01",
+		"limited_user_quotas": {"chat": 0, "completions": 1500},
+		"monthly_quotas": {"chat": 50, "completions": 2000}
+	}`)
+
+	tmpDir := t.TempDir()
+	proxyDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	if err := os.MkdirAll(proxyDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	proxyCred := `{"type": "github-copilot", "access_token": "gho_free"}`
+	if err := os.WriteFile(filepath.Join(proxyDir, "github-copilot-octocat.json"), []byte(proxyCred), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &CopilotProvider{homeDir: tmpDir, baseURL: server.URL, client: server.Client()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected chat and completions rows, got %+v", rows)
+	}
+	if rows[0].Label != "chat" || rows[0].UsagePercent != 100 || !rows[0].Blocked {
+		t.Errorf("expected exhausted chat quota, got %+v", rows[0])
+	}
+	if rows[1].Label != "completions" || rows[1].UsagePercent != 25 || rows[1].Blocked {
+		t.Errorf("expected completions at 25%%, got %+v", rows[1])
+	}
+	if rows[0].Provider != "Copilot (octocat)" || rows[0].Source != "proxy" || rows[0].ResetTime.IsZero() {
+		t.Errorf("unexpected row metadata: %+v", rows[0])
+	}
+}
+
+func TestCopilotProvider_FetchUsage_RejectedToken(t *testing.T) {
+	server := newCopilotServer(t, "gho_valid", `{}`)
+
+	homeDir := t.TempDir()
+	writeCopilotApps(t, homeDir, `{"github.com:Iv1.b507a08c87ecfe98": {"user": "octocat", "oauth_token": "gho_revoked"}}`)
+
+	provider := &CopilotProvider{homeDir: homeDir, baseURL: server.URL, client: server.Client()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || !rows[0].IsWarning || !strings.Contains(rows[0].WarningMsg, "Sign in to Copilot again") {
+		t.Errorf("expected sign-in warning, got %+v", rows)
+	}
+}
+
+func TestCopilotProvider_FetchUsage_NoCredentials(t *testing.T) {
+	provider := &CopilotProvider{homeDir: t.TempDir()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || !rows[0].IsWarning || !strings.Contains(rows[0].WarningMsg, "apps.json") {
+		t.Errorf("expected missing credentials warning, got %+v", rows)
+	}
+}
+
+func TestParseCopilotCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantUser string
+		wantErr  string
+	}{
+		{"username", `{"type": "github-copilot", "access_token": "gho_a", "username": "alice"}`, "alice", ""},
+		{"filename fallback", `{"oauth_token": "gho_a"}`, "octocat", ""},
+		{"other type", `{"type": "codex", "access_token": "tok"}`, "", "not github-copilot"},
+		{"missing token", `{"type": "github-copilot"}`, "", "missing access_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := parseCopilotCredentials([]byte(tt.data), "/tmp/github-copilot-octocat.json", "github-copilot-octocat.json")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if account.User != tt.wantUser || account.Token != "gho_a" {
+				t.Errorf("unexpected account: %+v", account)
+			}
+		})
+	}
+}
@@ -1,13 +1,118 @@
 package providers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
+
+	"github.com/charlieyou/aim/internal/filelock"
 )
 
+// DefaultRefreshSkew is how long before expiry tokens are refreshed proactively.
+const DefaultRefreshSkew = 5 * time.Minute
+
+// refreshPolicy decides when a provider refreshes tokens. The zero value
+// refreshes DefaultRefreshSkew before expiry.
+type refreshPolicy struct {
+	skew     time.Duration
+	disabled bool
+}
+
+func newRefreshPolicy(opts Options) refreshPolicy {
+	return refreshPolicy{skew: opts.RefreshSkew, disabled: opts.NoRefresh}
+}
+
+// dueBefore reports whether a token expiring at expiresAt should be refreshed
+// before use. Tokens with an unknown expiry are only refreshed after a 401.
+func (p refreshPolicy) dueBefore(expiresAt, now time.Time) bool {
+	if p.disabled || expiresAt.IsZero() {
+		return false
+	}
+	skew := p.skew
+	if skew <= 0 {
+		skew = DefaultRefreshSkew
+	}
+	return !now.Add(skew).Before(expiresAt)
+}
+
+// credentialLockPoll is how often lockCredentialFile retries a held lock.
+const credentialLockPoll = 50 * time.Millisecond
+
+// lockCredentialFile takes an exclusive advisory lock guarding the
+// read-refresh-write cycle of the credential file at path, waiting until ctx
+// is done. Credential files are replaced by rename, so the lock lives in a
+// separate file under aim's cache directory. Callers must re-read the
+// credentials after locking: refresh tokens are single-use, so a concurrent
+// refresh invalidates the one loaded earlier.
+func lockCredentialFile(ctx context.Context, path string) (func(), error) {
+	lockPath := credentialLockPath(path)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create credentials lock directory: %w", err)
+	}
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open credentials lock %s: %w", lockPath, err)
+	}
+
+	ticker := time.NewTicker(credentialLockPoll)
+	defer ticker.Stop()
+	for {
+		locked, err := filelock.TryLock(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to lock credentials file %s: %w", path, err)
+		}
+		if locked {
+			return func() {
+				_ = filelock.Unlock(file)
+				_ = file.Close()
+			}, nil
+		}
+		select {
+		case <-ctx.Done():
+			_ = file.Close()
+			return nil, fmt.Errorf("timed out waiting for credentials lock %s: %w", lockPath, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// credentialLockPath returns the lock file for the credential file at path:
+// $XDG_CACHE_HOME/aim/locks/<hash of the absolute path>.lock, so aim leaves
+// nothing behind in the credential directories it reads.
+func credentialLockPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(path))
+	name := hex.EncodeToString(sum[:8]) + ".lock"
+	if dir := defaultCachePath("locks"); dir != "" {
+		return filepath.Join(dir, name)
+	}
+	return filepath.Join(os.TempDir(), "aim-locks", name)
+}
+
+// invalidGrantBody matches the OAuth error response for a rejected grant.
+// Bodies are truncated, so this does not require the JSON to be complete.
+var invalidGrantBody = regexp.MustCompile(`"error"\s*:\s*"invalid_grant"`)
+
+// isInvalidGrant reports whether a token endpoint rejected the refresh token
+// itself, as it does when another process has already spent a single-use one.
+func isInvalidGrant(err error) bool {
+	var statusErr APIStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return invalidGrantBody.MatchString(statusErr.Body)
+}
+
 func updateJSONCredentials(path string, update func(map[string]any) error) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -68,3 +173,13 @@ func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
 func formatCredentialTime(ts time.Time) string {
 	return ts.UTC().Format(time.RFC3339Nano)
 }
+
+func parseCredentialTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return ts, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
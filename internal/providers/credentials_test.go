@@ -0,0 +1,125 @@
+//go:build unix
+
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLockCredentialFile_WaitsForHolder(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "codex-user@example.com.json")
+
+	unlock, err := lockCredentialFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("lockCredentialFile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := lockCredentialFile(ctx, path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error while lock is held, got %v", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		second, err := lockCredentialFile(context.Background(), path)
+		if err == nil {
+			second()
+		}
+		acquired <- err
+	}()
+	unlock()
+
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("second lockCredentialFile() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("lock not acquired after release")
+	}
+}
+
+func TestLockCredentialFile_LeavesCredentialDirClean(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("os.UserCacheDir ignores XDG_CACHE_HOME on macOS")
+	}
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+	authDir := t.TempDir()
+	path := filepath.Join(authDir, "codex-user@example.com.json")
+
+	unlock, err := lockCredentialFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("lockCredentialFile() error = %v", err)
+	}
+	unlock()
+
+	entries, err := os.ReadDir(authDir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("credential dir has %d entries after locking, want 0", len(entries))
+	}
+	lockPath := credentialLockPath(path)
+	if filepath.Dir(lockPath) != filepath.Join(cacheDir, "aim", "locks") {
+		t.Fatalf("lock path = %s, want it under %s", lockPath, cacheDir)
+	}
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("lock file missing: %v", err)
+	}
+	if other := credentialLockPath(filepath.Join(authDir, "codex-other@example.com.json")); other == lockPath {
+		t.Fatal("different credential files share a lock")
+	}
+}
+
+func TestIsInvalidGrant(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "oauth invalid_grant",
+			err:  APIStatusError{StatusCode: 400, Body: `{"error": "invalid_grant", "error_description": "Refresh token has been used"}`},
+			want: true,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("refresh failed: %w", APIStatusError{StatusCode: 400, Body: `{"error":"invalid_grant"}`}),
+			want: true,
+		},
+		{
+			name: "other bad request",
+			err:  APIStatusError{StatusCode: 400, Body: `{"error": "invalid_request", "error_description": "missing client_id"}`},
+			want: false,
+		},
+		{
+			name: "invalid_grant only in description",
+			err:  APIStatusError{StatusCode: 400, Body: `{"error": "invalid_client", "error_description": "not an invalid_grant"}`},
+			want: false,
+		},
+		{
+			name: "not a status error",
+			err:  errors.New("invalid_grant"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isInvalidGrant(tt.err); got != tt.want {
+				t.Errorf("isInvalidGrant() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"strings"
+	"time"
+)
+
+// credentialRank describes one credential file for choosing between copies
+// of the same upstream account.
+type credentialRank struct {
+	path      string
+	failed    bool // the file could not be loaded
+	native    bool
+	legacy    bool // the file uses a format the CLI no longer writes
+	expiresAt time.Time
+}
+
+// betterThan reports whether r should be used instead of other: loadable
+// files beat broken ones, proxy files beat native ones, then the token that
+// expires later wins, since stale copies hold older tokens, and finally
+// current file formats beat legacy ones.
+func (r credentialRank) betterThan(other credentialRank) bool {
+	if r.failed != other.failed {
+		return !r.failed
+	}
+	if r.native != other.native {
+		return !r.native
+	}
+	if !r.expiresAt.Equal(other.expiresAt) {
+		return r.expiresAt.After(other.expiresAt)
+	}
+	return !r.legacy && other.legacy
+}
+
+// dedupeAccounts collapses accounts that share a non-empty identity so each
+// upstream account is queried and shown once. The best-ranked credential of
+// a group takes the position of its first member; the paths of every file in
+// the group are logged in debug output. Accounts with an empty identity are
+// always kept.
+func dedupeAccounts[T any](provider string, accounts []T, identity func(T) string, rank func(T) credentialRank) []T {
+	index := make(map[string]int)
+	paths := make(map[string][]string)
+	kept := accounts[:0:0]
+	for _, account := range accounts {
+		id := identity(account)
+		if id == "" {
+			kept = append(kept, account)
+			continue
+		}
+		paths[id] = append(paths[id], rank(account).path)
+		if i, ok := index[id]; ok {
+			if rank(account).betterThan(rank(kept[i])) {
+				kept[i] = account
+			}
+			continue
+		}
+		index[id] = len(kept)
+		kept = append(kept, account)
+	}
+
+	for _, account := range kept {
+		if id := identity(account); len(paths[id]) > 1 {
+			debugf(provider, "account %s has %d credential files (%s), using %s",
+				id, len(paths[id]), strings.Join(paths[id], ", "), rank(account).path)
+		}
+	}
+	return kept
+}
+
+// duplicateCredentials maps the path of every credential file whose accounts
+// dedupeAccounts would all drop to the path of a copy it keeps instead.
+func duplicateCredentials[T any](accounts []T, identity func(T) string, rank func(T) credentialRank) map[string]string {
+	best := make(map[string]T)
+	for _, account := range accounts {
+		id := identity(account)
+		if id == "" {
+			continue
+		}
+		if kept, ok := best[id]; !ok || rank(account).betterThan(rank(kept)) {
+			best[id] = account
+		}
+	}
+
+	used := make(map[string]bool)
+	duplicates := make(map[string]string)
+	for _, account := range accounts {
+		path := rank(account).path
+		kept, ok := best[identity(account)]
+		if !ok || rank(kept).path == path {
+			used[path] = true
+			continue
+		}
+		duplicates[path] = rank(kept).path
+	}
+	for path := range used {
+		delete(duplicates, path)
+	}
+	return duplicates
+}
+
+// dropNativeDuplicates removes native accounts whose identity matches a proxy
+// account, for providers whose native credentials identify the account less
+// precisely than proxy files do. Accounts with an empty identity are always
+// kept.
+func dropNativeDuplicates[T any](provider string, accounts []T, identity func(T) string, rank func(T) credentialRank) []T {
+	proxyPaths := nativeDuplicateCredentials(accounts, identity, rank)
+	kept := accounts[:0:0]
+	for _, account := range accounts {
+		if proxyPath, ok := proxyPaths[rank(account).path]; ok && rank(account).native {
+			debugf(provider, "account %s is in both %s and %s, using %s",
+				identity(account), proxyPath, rank(account).path, proxyPath)
+			continue
+		}
+		kept = append(kept, account)
+	}
+	return kept
+}
+
+// nativeDuplicateCredentials maps the path of every native credential that
+// dropNativeDuplicates would drop to the path of the first proxy copy.
+func nativeDuplicateCredentials[T any](accounts []T, identity func(T) string, rank func(T) credentialRank) map[string]string {
+	proxyPaths := make(map[string]string)
+	for _, account := range accounts {
+		if id := identity(account); id != "" && !rank(account).native {
+			if _, ok := proxyPaths[id]; !ok {
+				proxyPaths[id] = rank(account).path
+			}
+		}
+	}
+	duplicates := make(map[string]string)
+	for _, account := range accounts {
+		if proxyPath, ok := proxyPaths[identity(account)]; ok && rank(account).native {
+			duplicates[rank(account).path] = proxyPath
+		}
+	}
+	return duplicates
+}
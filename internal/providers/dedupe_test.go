@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"testing"
+	"time"
+)
+
+type dedupeTestAccount struct {
+	id        string
+	path      string
+	failed    bool
+	native    bool
+	legacy    bool
+	expiresAt time.Time
+}
+
+func dedupeTestIdentity(a dedupeTestAccount) string { return a.id }
+
+func dedupeTestRank(a dedupeTestAccount) credentialRank {
+	return credentialRank{path: a.path, failed: a.failed, native: a.native, legacy: a.legacy, expiresAt: a.expiresAt}
+}
+
+func dedupeTestPaths(accounts []dedupeTestAccount) []string {
+	paths := make([]string, len(accounts))
+	for i, a := range accounts {
+		paths[i] = a.path
+	}
+	return paths
+}
+
+func TestDedupeAccounts(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		accounts []dedupeTestAccount
+		want     []string
+	}{
+		{
+			name: "later expiry wins in first position",
+			accounts: []dedupeTestAccount{
+				{id: "a", path: "a-stale.json", expiresAt: now},
+				{id: "b", path: "b.json"},
+				{id: "a", path: "a.json", expiresAt: now.Add(time.Hour)},
+			},
+			want: []string{"a.json", "b.json"},
+		},
+		{
+			name: "loadable file beats broken one",
+			accounts: []dedupeTestAccount{
+				{id: "a", path: "a-broken.json", failed: true, expiresAt: now.Add(time.Hour)},
+				{id: "a", path: "a.json", expiresAt: now},
+			},
+			want: []string{"a.json"},
+		},
+		{
+			name: "proxy beats native",
+			accounts: []dedupeTestAccount{
+				{id: "a", path: "native.json", native: true, expiresAt: now.Add(time.Hour)},
+				{id: "a", path: "a.json", expiresAt: now},
+			},
+			want: []string{"a.json"},
+		},
+		{
+			name: "current format beats legacy one",
+			accounts: []dedupeTestAccount{
+				{id: "a", path: "hosts.json", native: true, legacy: true},
+				{id: "a", path: "apps.json", native: true},
+			},
+			want: []string{"apps.json"},
+		},
+		{
+			name: "ties keep the first file",
+			accounts: []dedupeTestAccount{
+				{id: "a", path: "a-1.json"},
+				{id: "a", path: "a-2.json"},
+			},
+			want: []string{"a-1.json"},
+		},
+		{
+			name: "empty identity is never merged",
+			accounts: []dedupeTestAccount{
+				{path: "x.json"},
+				{path: "y.json"},
+			},
+			want: []string{"x.json", "y.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dedupeTestPaths(dedupeAccounts("Test", tt.accounts, dedupeTestIdentity, dedupeTestRank))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestDropNativeDuplicates(t *testing.T) {
+	accounts := []dedupeTestAccount{
+		{id: "a@example.com", path: "a.json"},
+		{id: "b@example.com", path: "b.json"},
+		{id: "a@example.com", path: "native-a.json", native: true},
+		{id: "c@example.com", path: "native-c.json", native: true},
+		{path: "native-unknown.json", native: true},
+	}
+
+	got := dedupeTestPaths(dropNativeDuplicates("Test", accounts, dedupeTestIdentity, dedupeTestRank))
+
+	want := []string{"a.json", "b.json", "native-c.json", "native-unknown.json"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestDuplicateCredentials(t *testing.T) {
+	now := time.Now()
+	accounts := []dedupeTestAccount{
+		{id: "a", path: "a-stale.json", expiresAt: now},
+		{id: "a", path: "a.json", expiresAt: now.Add(time.Hour)},
+		// apps.json holds a duplicate of b and the only copy of c, so it
+		// is still used.
+		{id: "b", path: "b.json", expiresAt: now.Add(time.Hour)},
+		{id: "b", path: "apps.json", native: true},
+		{id: "c", path: "apps.json", native: true},
+		{id: "", path: "unknown.json"},
+	}
+
+	got := duplicateCredentials(accounts, dedupeTestIdentity, dedupeTestRank)
+	if len(got) != 1 || got["a-stale.json"] != "a.json" {
+		t.Errorf("duplicateCredentials() = %v, want only a-stale.json -> a.json", got)
+	}
+}
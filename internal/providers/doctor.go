@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CredentialReport describes one credential file as seen by a provider.
+type CredentialReport struct {
+	Provider        string           // e.g. "Codex"
+	Path            string           // credential file path
+	Source          CredentialSource // where the file lives
+	Skipped         string           // why the provider does not read the file; empty if it does
+	DuplicateOf     string           // file holding the copy of the same account the provider uses instead
+	Type            string           // credential type stored in the file, or the provider's own for native files
+	Account         string           // email or account ID; empty if unknown
+	ExpiresAt       time.Time        // access token expiry; zero if unknown
+	HasRefreshToken bool             // whether the file holds a refresh token
+	Mode            os.FileMode      // file permission bits
+	Err             string           // why the provider cannot use the file; empty when it parses
+}
+
+// Loaded reports whether the provider reads the file.
+func (r CredentialReport) Loaded() bool {
+	return r.Skipped == ""
+}
+
+// ReadableByOthers reports whether every user on the system can read the
+// file's secrets.
+func (r CredentialReport) ReadableByOthers() bool {
+	return r.Mode.Perm()&0o004 != 0
+}
+
+// Diagnoser is implemented by providers that can describe every credential
+// file they would consider, including ones they skip.
+type Diagnoser interface {
+	DiagnoseCredentials() []CredentialReport
+}
+
+// newCredentialReport stats and parses the file at path enough to fill the
+// provider-independent fields. using names the active sources, as returned
+// by sourceNames. It reports false when the file does not exist.
+func newCredentialReport(provider, path string, source CredentialSource, active []CredentialSource, using []string) (CredentialReport, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return CredentialReport{}, false
+	}
+	report := CredentialReport{
+		Provider: provider,
+		Path:     path,
+		Source:   source,
+		Mode:     info.Mode().Perm(),
+	}
+	if !slices.Contains(active, source) {
+		report.Skipped = "using " + strings.Join(using, " and ")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		report.Err = fmt.Sprintf("failed to read file: %v", err)
+		return report, true
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		report.Err = fmt.Sprintf("failed to parse JSON: %v", err)
+		return report, true
+	}
+	if typ, ok := raw["type"].(string); ok {
+		report.Type = typ
+	}
+	return report, true
+}
+
+// setLoaded records the account a provider loaded from the file. typ is the
+// provider's credential type, used when the file does not name one.
+func (r *CredentialReport) setLoaded(typ, account string, expiresAt time.Time, hasRefreshToken bool) {
+	if r.Type == "" {
+		r.Type = typ
+	}
+	r.Account = account
+	r.ExpiresAt = expiresAt
+	r.HasRefreshToken = hasRefreshToken
+}
+
+// markDuplicates records, for each report, the file holding the copy of the
+// same account the provider uses instead, as found by duplicateCredentials.
+func markDuplicates(reports []CredentialReport, duplicates map[string]string) {
+	for i := range reports {
+		reports[i].DuplicateOf = duplicates[reports[i].Path]
+	}
+}
+
+// globCredentialFiles returns the files in dirs matching pattern, sorted
+// within each directory. Empty and repeated directories are skipped.
+func globCredentialFiles(dirs []string, pattern string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if dir == "" || seen[filepath.Clean(dir)] {
+			continue
+		}
+		seen[filepath.Clean(dir)] = true
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files
+}
@@ -0,0 +1,178 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClaudeProvider_DiagnoseCredentials(t *testing.T) {
+	homeDir := t.TempDir()
+	proxyDir := filepath.Join(homeDir, ".cli-proxy-api")
+	nativeDir := filepath.Join(homeDir, ".claude")
+	for _, dir := range []string{proxyDir, nativeDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := []struct {
+		path string
+		data string
+		mode os.FileMode
+	}{
+		{filepath.Join(proxyDir, "claude-a@example.com.json"),
+			`{"access_token": "tok", "refresh_token": "ref", "type": "claude", "expired": "2030-01-02T03:04:05Z"}`, 0600},
+		{filepath.Join(proxyDir, "claude-b@example.com.json"), `{"type": "codex", "access_token": "tok"}`, 0644},
+		{filepath.Join(nativeDir, ".credentials.json"), `{"claudeAiOauth": {"accessToken": "tok"}}`, 0600},
+		{filepath.Join(homeDir, ".claude.json"), `{"oauthAccount": {"emailAddress": "native@example.com"}}`, 0640},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(f.path, []byte(f.data), f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(f.path, f.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &ClaudeProvider{homeDir: homeDir}
+	reports := p.DiagnoseCredentials()
+	if len(reports) != 3 {
+		t.Fatalf("expected 3 reports, got %+v", reports)
+	}
+
+	good := reports[0]
+	if good.Err != "" || !good.Loaded() || good.Type != "claude" || good.Account != "a@example.com" ||
+		!good.HasRefreshToken || !good.ExpiresAt.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) || good.ReadableByOthers() {
+		t.Errorf("unexpected report for valid proxy file: %+v", good)
+	}
+
+	wrongType := reports[1]
+	if wrongType.Type != "codex" || wrongType.Err == "" || !wrongType.ReadableByOthers() {
+		t.Errorf("expected type error and permission flag, got %+v", wrongType)
+	}
+
+	native := reports[2]
+	if native.Source != SourceNative || native.Loaded() || native.Err != "" || native.HasRefreshToken ||
+		native.Account != "native@example.com" {
+		t.Errorf("expected native file parsed but not loaded, got %+v", native)
+	}
+}
+
+func TestCredentialReport_ReadableByOthers(t *testing.T) {
+	tests := []struct {
+		mode os.FileMode
+		want bool
+	}{
+		{0600, false},
+		{0640, false},
+		{0604, true},
+		{0644, true},
+	}
+	for _, tt := range tests {
+		if got := (CredentialReport{Mode: tt.mode}).ReadableByOthers(); got != tt.want {
+			t.Errorf("ReadableByOthers() for %#o = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestClaudeProvider_DiagnoseCredentials_MarksDuplicates(t *testing.T) {
+	proxyDir := t.TempDir()
+	stale := filepath.Join(proxyDir, "claude-a-old.json")
+	fresh := filepath.Join(proxyDir, "claude-a.json")
+	other := filepath.Join(proxyDir, "claude-b.json")
+	for path, data := range map[string]string{
+		stale: `{"access_token": "old", "type": "claude", "email": "a@example.com", "expired": "2030-01-01T00:00:00Z"}`,
+		fresh: `{"access_token": "new", "type": "claude", "email": "a@example.com", "expired": "2030-02-01T00:00:00Z"}`,
+		other: `{"access_token": "tok", "type": "claude", "email": "b@example.com", "expired": "2030-01-01T00:00:00Z"}`,
+	} {
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &ClaudeProvider{proxyDirs: []string{proxyDir}}
+	reports := p.DiagnoseCredentials()
+	got := make(map[string]string)
+	for _, report := range reports {
+		got[report.Path] = report.DuplicateOf
+	}
+	want := map[string]string{stale: fresh, fresh: "", other: ""}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d reports, got %+v", len(want), reports)
+	}
+	for path, duplicateOf := range want {
+		if got[path] != duplicateOf {
+			t.Errorf("DuplicateOf for %s = %q, want %q", filepath.Base(path), got[path], duplicateOf)
+		}
+	}
+}
+
+func TestGeminiProvider_DiagnoseCredentials_ReportsSkippedFiles(t *testing.T) {
+	proxyDir := t.TempDir()
+	path := filepath.Join(proxyDir, "gemini-other.json")
+	if err := os.WriteFile(path, []byte(`{"type": "antigravity", "token": {"access_token": "tok"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &GeminiProvider{proxyDirs: []string{proxyDir}}
+	reports := p.DiagnoseCredentials()
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %+v", reports)
+	}
+	if reports[0].Loaded() || reports[0].Type != "antigravity" || reports[0].Err != "" {
+		t.Errorf("expected a skipped file with its type, got %+v", reports[0])
+	}
+}
+
+func TestGeminiProvider_DiagnoseCredentials_SkippedNoteNamesProxyDir(t *testing.T) {
+	homeDir := t.TempDir()
+	proxyDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(proxyDir, "gemini-a.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	nativeDir := filepath.Join(homeDir, ".gemini")
+	if err := os.MkdirAll(nativeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nativeDir, "oauth_creds.json"), []byte(`{"access_token": "tok"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &GeminiProvider{homeDir: homeDir, proxyDirs: []string{proxyDir}}
+	reports := p.DiagnoseCredentials()
+	if len(reports) != 2 {
+		t.Fatalf("expected proxy and native reports, got %+v", reports)
+	}
+	if want := "using " + proxyDir; reports[1].Skipped != want {
+		t.Errorf("Skipped = %q, want %q", reports[1].Skipped, want)
+	}
+}
+
+func TestGlobCredentialFiles_MultipleDirs(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	for _, path := range []string{
+		filepath.Join(first, "codex-b.json"),
+		filepath.Join(first, "codex-a.json"),
+		filepath.Join(first, "claude-a.json"),
+		filepath.Join(second, "codex-c.json"),
+	} {
+		if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := globCredentialFiles([]string{first, "", second, first + "/"}, "codex-*.json")
+	want := []string{
+		filepath.Join(first, "codex-a.json"),
+		filepath.Join(first, "codex-b.json"),
+		filepath.Join(second, "codex-c.json"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("globCredentialFiles() = %q, want %q", got, want)
+	}
+}
@@ -1,16 +1,18 @@
 package providers
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
+	"slices"
 	"strings"
 	"time"
 )
@@ -18,8 +20,10 @@ import (
 const (
 	geminiDefaultBaseURL = "https://cloudcode-pa.googleapis.com"
 	geminiEndpoint       = "/v1internal:retrieveUserQuota"
+	geminiLoadEndpoint   = "/v1internal:loadCodeAssist"
 	geminiHTTPTimeout    = 30 * time.Second
 	geminiTokenURI       = "https://oauth2.googleapis.com/token"
+	geminiProxyPattern   = "gemini-*.json"
 )
 
 // GeminiAccount holds credentials for a single Gemini account
@@ -34,14 +38,22 @@ type GeminiAccount struct {
 	ProjectID      string
 	CredentialPath string
 	IsNative       bool
+	IsRemote       bool   // loaded from the management API, which owns the refresh token
 	LoadErr        string // Error message from loading credentials, if any
+	NativeEmail    string // active account from ~/.gemini/google_accounts.json; native only
+	DisplayName    string // email, plus the project when the email has several
 }
 
 // GeminiProvider fetches usage data from Gemini (Google) quota API
 type GeminiProvider struct {
-	homeDir string
-	baseURL string
-	client  *http.Client
+	homeDir    string
+	proxyDirs  []string
+	baseURL    string
+	client     *http.Client
+	assist     geminiCodeAssistCache
+	refresh    refreshPolicy
+	source     SourceMode
+	management *managementClient
 }
 
 // geminiCredFile represents the structure of ~/.cli-proxy-api/gemini-*.json files
@@ -72,6 +84,38 @@ type geminiQuotaBucket struct {
 	ResetTime         string  `json:"resetTime"`
 }
 
+// geminiLoadCodeAssistResponse is the subscription info returned by loadCodeAssist.
+type geminiLoadCodeAssistResponse struct {
+	CurrentTier  *geminiTier  `json:"currentTier"`
+	AllowedTiers []geminiTier `json:"allowedTiers"`
+	// Project is either a project ID string or an object with an "id" field.
+	Project json.RawMessage `json:"cloudaicompanionProject"`
+}
+
+// projectID extracts the Code Assist project ID from the response.
+func (r *geminiLoadCodeAssistResponse) projectID() string {
+	if len(r.Project) == 0 {
+		return ""
+	}
+	var id string
+	if err := json.Unmarshal(r.Project, &id); err == nil {
+		return id
+	}
+	var project struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(r.Project, &project); err == nil {
+		return project.ID
+	}
+	return ""
+}
+
+type geminiTier struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"isDefault"`
+}
+
 type geminiRefreshResponse struct {
 	AccessToken string `json:"access_token"`
 	ExpiresIn   int    `json:"expires_in"`
@@ -80,30 +124,57 @@ type geminiRefreshResponse struct {
 
 var errNotGeminiCred = errors.New("not gemini credential")
 
+// GeminiName is the name Gemini is registered under and the prefix of its rows.
+const GeminiName = "Gemini"
+
+func init() {
+	Register(Registration{
+		Name:  GeminiName,
+		Order: 2,
+		New:   func(opts Options) (Provider, error) { return NewGeminiProviderWithOptions(opts) },
+	})
+}
+
 // NewGeminiProvider creates a new GeminiProvider with default settings
 func NewGeminiProvider() (*GeminiProvider, error) {
+	return NewGeminiProviderWithOptions(Options{})
+}
+
+// NewGeminiProviderWithOptions creates a new GeminiProvider with the given options
+func NewGeminiProviderWithOptions(opts Options) (*GeminiProvider, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
+	timeout := geminiHTTPTimeout
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+	}
 	return &GeminiProvider{
-		homeDir: homeDir,
-		baseURL: geminiDefaultBaseURL,
-		client: &http.Client{
-			Timeout: geminiHTTPTimeout,
-		},
+		homeDir:    homeDir,
+		proxyDirs:  opts.CredentialsDirs,
+		baseURL:    geminiDefaultBaseURL,
+		client:     client,
+		assist:     geminiCodeAssistCache{path: defaultCachePath("gemini-code-assist.json")},
+		refresh:    newRefreshPolicy(opts),
+		source:     opts.Source,
+		management: newManagementClient(opts, client),
 	}, nil
 }
 
 // Name returns the provider name
 func (g *GeminiProvider) Name() string {
-	return "Gemini"
+	return GeminiName
 }
 
 // FetchUsage fetches usage data from all discovered Gemini accounts
 func (g *GeminiProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
-	accounts, warnings, source := g.loadCredentials()
+	accounts, warnings := g.loadCredentialsContext(ctx)
 
 	var rows []UsageRow
 
@@ -120,7 +191,7 @@ func (g *GeminiProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
 		rows = append(rows, UsageRow{
 			Provider:   "Gemini",
 			IsWarning:  true,
-			WarningMsg: fmt.Sprintf("No valid credentials found for %s", source.DisplayName()),
+			WarningMsg: fmt.Sprintf("No valid credentials found for %s", g.sourceDisplayName()),
 		})
 		return rows, nil
 	}
@@ -129,19 +200,112 @@ func (g *GeminiProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
 	for _, account := range accounts {
 		accountRows, err := g.fetchAccountUsage(ctx, account)
 		if err != nil {
-			rows = append(rows, UsageRow{
-				Provider:   fmt.Sprintf("Gemini (%s)", account.Email),
+			accountRows = []UsageRow{{
+				Provider:   geminiProviderName(account),
 				IsWarning:  true,
 				WarningMsg: err.Error(),
-			})
-			continue
+			}}
 		}
+		setSource(accountRows, geminiAccountSource(account))
 		rows = append(rows, accountRows...)
 	}
 
 	return rows, nil
 }
 
+// RefreshCredentials refreshes proxy tokens that are due without fetching usage.
+func (g *GeminiProvider) RefreshCredentials(ctx context.Context, force bool) []RefreshResult {
+	accounts, warnings := g.loadCredentialsContext(ctx)
+
+	results := make([]RefreshResult, 0, len(warnings)+len(accounts))
+	for _, w := range warnings {
+		results = append(results, RefreshResult{Provider: g.Name(), Status: RefreshFailed, Message: w})
+	}
+
+	now := time.Now()
+	for _, account := range accounts {
+		results = append(results, refreshCredential(ctx, g.refresh, force, now, refreshCandidate{
+			providerName:    geminiProviderName(account),
+			loadErr:         account.LoadErr,
+			native:          account.IsNative,
+			remote:          account.IsRemote,
+			hasRefreshToken: account.RefreshToken != "" && account.ClientID != "",
+			expiresAt:       account.TokenExpiry,
+			refresh: func(ctx context.Context) (time.Time, bool, error) {
+				_, reused, err := g.refreshOrReuseAccessToken(ctx, account)
+				if err != nil {
+					return time.Time{}, false, err
+				}
+				updated, err := g.parseCredFile(account.CredentialPath, geminiCredBaseName(account.CredentialPath))
+				if err != nil {
+					return time.Time{}, reused, fmt.Errorf("token refreshed, but reloading credentials failed: %w", err)
+				}
+				return updated.TokenExpiry, reused, nil
+			},
+		}))
+	}
+	return results
+}
+
+// DiagnoseCredentials reports on every Gemini credential file, proxy and
+// native, including proxy files skipped because they hold another type.
+func (g *GeminiProvider) DiagnoseCredentials() []CredentialReport {
+	active := g.CredentialSources()
+	using := sourceNames(active, g.proxyDirs, g.management)
+	var reports []CredentialReport
+	var accounts []GeminiAccount
+	for _, path := range globCredentialFiles(g.credentialDirs(), geminiProxyPattern) {
+		report, ok := newCredentialReport(g.Name(), path, SourceProxy, active, using)
+		if !ok {
+			continue
+		}
+		if report.Err == "" {
+			account, err := g.parseCredFile(path, geminiCredBaseName(path))
+			switch {
+			case errors.Is(err, errNotGeminiCred):
+				report.Skipped = fmt.Sprintf("type %q is not a Gemini credential", report.Type)
+			case err != nil:
+				report.Err = err.Error()
+			default:
+				report.setLoaded("gemini", account.Email, account.TokenExpiry, account.RefreshToken != "")
+				if report.Loaded() {
+					accounts = append(accounts, *account)
+				}
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	if g.homeDir != "" {
+		nativePath := filepath.Join(g.homeDir, ".gemini", "oauth_creds.json")
+		if report, ok := newCredentialReport(g.Name(), nativePath, SourceNative, active, using); ok {
+			if report.Err == "" {
+				for _, account := range g.loadNativeCredentials() {
+					if account.LoadErr != "" {
+						report.Err = account.LoadErr
+					} else {
+						report.setLoaded("gemini", account.NativeEmail, account.TokenExpiry, account.RefreshToken != "")
+						if report.Loaded() {
+							accounts = append(accounts, account)
+						}
+					}
+				}
+			}
+			reports = append(reports, report)
+		}
+	}
+
+	// Mirror loadCredentialsContext: proxy copies collapse first, then a
+	// native account already held by a kept proxy file is dropped.
+	duplicates := duplicateCredentials(accounts, geminiAccountIdentity, geminiCredentialRank)
+	kept := slices.DeleteFunc(slices.Clone(accounts), func(account GeminiAccount) bool {
+		return duplicates[account.CredentialPath] != ""
+	})
+	maps.Copy(duplicates, nativeDuplicateCredentials(kept, geminiAccountEmail, geminiCredentialRank))
+	markDuplicates(reports, duplicates)
+	return reports
+}
+
 // nativeGeminiCred represents the structure of ~/.gemini/oauth_creds.json
 type nativeGeminiCred struct {
 	AccessToken  string `json:"access_token"`
@@ -149,6 +313,26 @@ type nativeGeminiCred struct {
 	ExpiryDate   int64  `json:"expiry_date"`
 }
 
+// geminiAccounts is Gemini CLI's ~/.gemini/google_accounts.json, which
+// records the signed-in account.
+type geminiAccounts struct {
+	Active string `json:"active"`
+}
+
+// nativeAccountEmail returns the email Gemini CLI is signed in with, or ""
+// if ~/.gemini/google_accounts.json is missing or does not record one.
+func (g *GeminiProvider) nativeAccountEmail() string {
+	data, err := os.ReadFile(filepath.Join(g.homeDir, ".gemini", "google_accounts.json"))
+	if err != nil {
+		return ""
+	}
+	var accounts geminiAccounts
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return ""
+	}
+	return accounts.Active
+}
+
 // loadNativeCredentials loads credentials from ~/.gemini/oauth_creds.json
 // Returns an account with LoadErr set if parsing fails, allowing the caller to
 // report a single account-specific warning row.
@@ -200,39 +384,100 @@ func (g *GeminiProvider) loadNativeCredentials() []GeminiAccount {
 		TokenExpiry:    tokenExpiry,
 		IsNative:       true,
 		CredentialPath: credPath,
+		NativeEmail:    g.nativeAccountEmail(),
 	}
 
 	return []GeminiAccount{account}
 }
 
-// loadCredentials discovers and loads credentials based on global credential source
-func (g *GeminiProvider) loadCredentials() ([]GeminiAccount, []string, CredentialSource) {
+// credentialDirs returns the CLIProxyAPI credential directories for this provider.
+func (g *GeminiProvider) credentialDirs() []string {
+	return ProxyCredentialDirs(g.homeDir, g.proxyDirs)
+}
+
+// CredentialSources returns the credential sources loaded under the
+// configured source mode, proxy first.
+func (g *GeminiProvider) CredentialSources() []CredentialSource {
+	return g.source.sources(g.credentialDirs(), geminiProxyPattern, g.management != nil)
+}
+
+// sourceDisplayName describes where credentials are loaded from for warnings.
+func (g *GeminiProvider) sourceDisplayName() string {
+	names := sourceNames(g.CredentialSources(), g.proxyDirs, g.management)
+	return strings.Join(names, " or ")
+}
+
+// loadCredentials is loadCredentialsContext for callers without a context.
+func (g *GeminiProvider) loadCredentials() ([]GeminiAccount, []string) {
+	return g.loadCredentialsContext(context.Background())
+}
+
+// loadCredentialsContext loads accounts from every selected credential source,
+// keeping one credential per account and project when several files hold it.
+// The native account's project is only known after loadCodeAssist, so it is
+// dropped when any proxy file has the same email.
+func (g *GeminiProvider) loadCredentialsContext(ctx context.Context) ([]GeminiAccount, []string) {
 	var accounts []GeminiAccount
 	var warnings []string
-
-	source := DetectCredentialSource(g.homeDir)
-	if source == SourceNative {
-		nativeAccounts := g.loadNativeCredentials()
-		return nativeAccounts, warnings, source
+	for _, source := range g.CredentialSources() {
+		var loaded []GeminiAccount
+		var loadWarnings []string
+		switch source {
+		case SourceNative:
+			loaded = g.loadNativeCredentials()
+		case SourceManagement:
+			loaded, loadWarnings = g.loadManagementCredentials(ctx)
+		default:
+			loaded, loadWarnings = g.loadProxyCredentials()
+		}
+		accounts = append(accounts, loaded...)
+		warnings = append(warnings, loadWarnings...)
 	}
+	accounts = dedupeAccounts(g.Name(), accounts, geminiAccountIdentity, geminiCredentialRank)
+	accounts = dropNativeDuplicates(g.Name(), accounts, geminiAccountEmail, geminiCredentialRank)
+	applyGeminiDisplayNames(accounts)
+	return accounts, warnings
+}
 
-	// SourceProxy: load from ~/.cli-proxy-api/gemini-*.json
-	pattern := filepath.Join(g.homeDir, ".cli-proxy-api", "gemini-*.json")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		warnings = append(warnings, fmt.Sprintf("Failed to glob %s: %v", pattern, err))
-		return accounts, warnings, source
+// applyGeminiDisplayNames labels accounts by email, adding the project when
+// one email holds credentials for several projects so their rows and
+// metrics stay apart.
+func applyGeminiDisplayNames(accounts []GeminiAccount) {
+	projects := make(map[string]map[string]bool)
+	for _, account := range accounts {
+		key := strings.ToLower(account.Email)
+		if projects[key] == nil {
+			projects[key] = make(map[string]bool)
+		}
+		projects[key][account.ProjectID] = true
+	}
+	for i := range accounts {
+		accounts[i].DisplayName = accounts[i].Email
+		if len(projects[strings.ToLower(accounts[i].Email)]) > 1 && accounts[i].ProjectID != "" {
+			accounts[i].DisplayName = accounts[i].Email + "/" + accounts[i].ProjectID
+		}
 	}
+}
+
+// geminiProviderName returns the row provider name for an account, e.g.
+// "Gemini (user@example.com)".
+func geminiProviderName(account GeminiAccount) string {
+	return fmt.Sprintf("%s (%s)", GeminiName, cmp.Or(account.DisplayName, account.Email))
+}
+
+// loadProxyCredentials loads gemini-*.json from the proxy directories
+// (~/.cli-proxy-api by default).
+func (g *GeminiProvider) loadProxyCredentials() ([]GeminiAccount, []string) {
+	var accounts []GeminiAccount
+	var warnings []string
 
-	sort.Strings(matches)
+	matches := globCredentialFiles(g.credentialDirs(), geminiProxyPattern)
 
 	for _, filePath := range matches {
 		name := filepath.Base(filePath)
-		baseName := strings.TrimSuffix(name, ".json")
-		baseName = strings.TrimPrefix(baseName, "gemini-")
 
 		// Read and parse the file
-		account, err := g.parseCredFile(filePath, baseName)
+		account, err := g.parseCredFile(filePath, geminiCredBaseName(filePath))
 		if err != nil {
 			if errors.Is(err, errNotGeminiCred) {
 				continue
@@ -245,7 +490,80 @@ func (g *GeminiProvider) loadCredentials() ([]GeminiAccount, []string, Credentia
 		accounts = append(accounts, *account)
 	}
 
-	return accounts, warnings, source
+	return accounts, warnings
+}
+
+// geminiAccountIdentity identifies a proxy account by email and project, as
+// quota is tracked per project. Native accounts have no identity here.
+func geminiAccountIdentity(account GeminiAccount) string {
+	email := geminiAccountEmail(account)
+	if account.IsNative || email == "" {
+		return ""
+	}
+	return email + "/" + account.ProjectID
+}
+
+// loadManagementCredentials loads gemini auth files from the CLIProxyAPI
+// management API. The proxy keeps refreshing these tokens, so aim drops the
+// refresh token rather than racing it for a rotation.
+func (g *GeminiProvider) loadManagementCredentials(ctx context.Context) ([]GeminiAccount, []string) {
+	if g.management == nil {
+		return nil, []string{"No CLIProxyAPI management URL configured"}
+	}
+	files, err := g.management.authFiles(ctx, "gemini", geminiProxyPattern)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+
+	var accounts []GeminiAccount
+	var warnings []string
+	for _, file := range files {
+		account, err := g.parseCredData(file.Data, file.Location, geminiCredBaseName(file.Name))
+		if err != nil {
+			if !errors.Is(err, errNotGeminiCred) {
+				warnings = append(warnings, fmt.Sprintf("Failed to parse %s: %v", file.Name, err))
+			}
+			continue
+		}
+		account.RefreshToken = ""
+		account.IsRemote = true
+		accounts = append(accounts, *account)
+	}
+	return accounts, warnings
+}
+
+func geminiAccountEmail(account GeminiAccount) string {
+	email := account.Email
+	if account.IsNative {
+		email = account.NativeEmail
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func geminiCredentialRank(account GeminiAccount) credentialRank {
+	return credentialRank{
+		path:      account.CredentialPath,
+		failed:    account.LoadErr != "",
+		native:    account.IsNative,
+		expiresAt: account.TokenExpiry,
+	}
+}
+
+func geminiAccountSource(account GeminiAccount) CredentialSource {
+	if account.IsNative {
+		return SourceNative
+	}
+	if account.IsRemote {
+		return SourceManagement
+	}
+	return SourceProxy
+}
+
+// geminiCredBaseName strips the extension and "gemini-" prefix from a proxy
+// credential filename, leaving "{email}-{project_id}".
+func geminiCredBaseName(filePath string) string {
+	baseName := strings.TrimSuffix(filepath.Base(filePath), ".json")
+	return strings.TrimPrefix(baseName, "gemini-")
 }
 
 // parseCredFile reads and validates a credential file
@@ -254,7 +572,12 @@ func (g *GeminiProvider) parseCredFile(filePath, baseName string) (*GeminiAccoun
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	return g.parseCredData(data, filePath, baseName)
+}
 
+// parseCredData parses a proxy auth file read from filePath; baseName is its
+// file name without the "gemini-" prefix and ".json" suffix.
+func (g *GeminiProvider) parseCredData(data []byte, filePath, baseName string) (*GeminiAccount, error) {
 	var cred geminiCredFile
 	if err := json.Unmarshal(data, &cred); err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
@@ -316,11 +639,16 @@ func (g *GeminiProvider) fetchAccountUsage(ctx context.Context, account GeminiAc
 		return nil, fmt.Errorf("failed to load credentials: %s", account.LoadErr)
 	}
 
-	token, err := g.accessTokenForAccount(ctx, account)
+	token, refreshed, err := g.accessTokenForAccount(ctx, account)
 	if err != nil {
 		return nil, err
 	}
 
+	info, infoErr := g.codeAssistInfo(ctx, account, token)
+	if account.ProjectID == "" {
+		account.ProjectID = info.Project
+	}
+
 	body, status, err := g.doQuotaRequest(ctx, account, token)
 	if err != nil {
 		return nil, err
@@ -332,14 +660,20 @@ func (g *GeminiProvider) fetchAccountUsage(ctx context.Context, account GeminiAc
 			return nil, fmt.Errorf("token expired. Re-authenticate with gemini to refresh")
 		}
 		// For proxy credentials, attempt refresh if we have a refresh token
-		if account.RefreshToken != "" {
-			debugf("Gemini", "attempting token refresh after status=%d for %s", status, fmt.Sprintf("Gemini (%s)", account.Email))
+		if account.RefreshToken != "" && !refreshed && !g.refresh.disabled {
+			debugf("Gemini", "attempting token refresh after status=%d for %s", status, geminiProviderName(account))
 			token, err = g.refreshAccessToken(ctx, account)
 			if err != nil {
-				debugf("Gemini", "token refresh failed for %s: %v", fmt.Sprintf("Gemini (%s)", account.Email), err)
+				debugf("Gemini", "token refresh failed for %s: %v", geminiProviderName(account), err)
 				return nil, err
 			}
-			debugf("Gemini", "token refresh succeeded, retrying quota API for %s", fmt.Sprintf("Gemini (%s)", account.Email))
+			debugf("Gemini", "token refresh succeeded, retrying quota API for %s", geminiProviderName(account))
+			if infoErr != nil {
+				info, infoErr = g.codeAssistInfo(ctx, account, token)
+				if account.ProjectID == "" {
+					account.ProjectID = info.Project
+				}
+			}
 			body, status, err = g.doQuotaRequest(ctx, account, token)
 			if err != nil {
 				return nil, err
@@ -361,7 +695,7 @@ func (g *GeminiProvider) fetchAccountUsage(ctx context.Context, account GeminiAc
 	// Check for empty buckets
 	if len(quotaResp.Buckets) == 0 {
 		return []UsageRow{{
-			Provider:   fmt.Sprintf("Gemini (%s)", account.Email),
+			Provider:   geminiProviderName(account),
 			IsWarning:  true,
 			WarningMsg: "Empty buckets array in response",
 		}}, nil
@@ -370,10 +704,10 @@ func (g *GeminiProvider) fetchAccountUsage(ctx context.Context, account GeminiAc
 	// Convert buckets to usage rows
 	var rows []UsageRow
 	for _, bucket := range quotaResp.Buckets {
-		row, err := g.bucketToRow(account.Email, bucket)
+		row, err := g.bucketToRow(geminiProviderName(account), bucket)
 		if err != nil {
 			rows = append(rows, UsageRow{
-				Provider:   fmt.Sprintf("Gemini (%s)", account.Email),
+				Provider:   geminiProviderName(account),
 				Label:      bucket.ModelID,
 				IsWarning:  true,
 				WarningMsg: fmt.Sprintf("Parse error: %v", err),
@@ -383,24 +717,105 @@ func (g *GeminiProvider) fetchAccountUsage(ctx context.Context, account GeminiAc
 		rows = append(rows, row)
 	}
 
+	setPlan(rows, info.Tier)
 	return rows, nil
 }
 
-func (g *GeminiProvider) accessTokenForAccount(ctx context.Context, account GeminiAccount) (string, error) {
+// accessTokenForAccount returns a usable access token, refreshing proxy tokens
+// that are about to expire. refreshed reports whether a refresh was attempted.
+func (g *GeminiProvider) accessTokenForAccount(ctx context.Context, account GeminiAccount) (token string, refreshed bool, err error) {
 	if account.Token == "" {
-		return "", fmt.Errorf("missing access token")
+		return "", false, fmt.Errorf("missing access token")
 	}
-	return account.Token, nil
+	if account.IsNative || account.RefreshToken == "" || !g.refresh.dueBefore(account.TokenExpiry, time.Now()) {
+		return account.Token, false, nil
+	}
+	providerName := geminiProviderName(account)
+	debugf("Gemini", "token for %s expires at %s, refreshing before quota request", providerName, account.TokenExpiry.Format(time.RFC3339))
+	token, err = g.refreshAccessToken(ctx, account)
+	if err != nil {
+		if time.Now().Before(account.TokenExpiry) {
+			// The current token is still valid, so a token endpoint outage
+			// should not hide usage until it actually expires.
+			debugf("Gemini", "token refresh failed for %s, using current token until it expires: %v", providerName, err)
+			return account.Token, true, nil
+		}
+		debugf("Gemini", "token refresh failed for %s: %v", providerName, err)
+		return "", false, err
+	}
+	return token, true, nil
 }
 
+// refreshAccessToken refreshes the access token in the credential file and
+// returns it.
 func (g *GeminiProvider) refreshAccessToken(ctx context.Context, account GeminiAccount) (string, error) {
+	token, _, err := g.refreshOrReuseAccessToken(ctx, account)
+	return token, err
+}
+
+// refreshOrReuseAccessToken is refreshAccessToken that also reports whether
+// another process had already refreshed the token, in which case the token
+// it wrote is returned without calling the token endpoint.
+func (g *GeminiProvider) refreshOrReuseAccessToken(ctx context.Context, account GeminiAccount) (string, bool, error) {
 	if account.IsNative {
-		return "", fmt.Errorf("token expired. Re-authenticate with gemini to refresh")
+		return "", false, fmt.Errorf("token expired. Re-authenticate with gemini to refresh")
+	}
+	if account.IsRemote {
+		return "", false, fmt.Errorf("token expired. CLIProxyAPI refreshes management API credentials")
 	}
 	if account.RefreshToken == "" || account.ClientID == "" {
-		return "", fmt.Errorf("refresh token not available")
+		return "", false, fmt.Errorf("refresh token not available")
+	}
+	if account.CredentialPath == "" {
+		return "", false, fmt.Errorf("credential path not available for refresh")
+	}
+
+	unlock, err := lockCredentialFile(ctx, account.CredentialPath)
+	if err != nil {
+		return "", false, err
+	}
+	defer unlock()
+
+	// CLIProxyAPI or another aim process may have rotated the tokens since they
+	// were loaded. Reuse a newer access token rather than refreshing again.
+	if current, err := g.parseCredFile(account.CredentialPath, geminiCredBaseName(account.CredentialPath)); err == nil {
+		if current.Token != account.Token && !g.refresh.dueBefore(current.TokenExpiry, time.Now()) {
+			debugf(GeminiName, "credentials for %s were refreshed concurrently, reusing newer token", geminiProviderName(account))
+			return current.Token, true, nil
+		}
+		if current.RefreshToken != "" {
+			account.RefreshToken = current.RefreshToken
+		}
 	}
 
+	refreshResp, err := g.requestTokenRefresh(ctx, account, account.RefreshToken)
+	if isInvalidGrant(err) {
+		// CLIProxyAPI does not take aim's lock, so it may have replaced the
+		// refresh token after it was read above. Pick up what it wrote.
+		if current, loadErr := g.parseCredFile(account.CredentialPath, geminiCredBaseName(account.CredentialPath)); loadErr == nil &&
+			current.RefreshToken != "" && current.RefreshToken != account.RefreshToken {
+			if current.Token != account.Token && !g.refresh.dueBefore(current.TokenExpiry, time.Now()) {
+				debugf(GeminiName, "refresh token for %s was replaced by another process, reusing newer token", geminiProviderName(account))
+				return current.Token, true, nil
+			}
+			debugf(GeminiName, "refresh token for %s was replaced by another process, retrying with the newer one", geminiProviderName(account))
+			refreshResp, err = g.requestTokenRefresh(ctx, account, current.RefreshToken)
+		}
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	if err := updateGeminiCredentialFile(account.CredentialPath, refreshResp); err != nil {
+		return "", false, err
+	}
+
+	return refreshResp.AccessToken, false, nil
+}
+
+// requestTokenRefresh exchanges refreshToken for a new access token using
+// the account's OAuth client.
+func (g *GeminiProvider) requestTokenRefresh(ctx context.Context, account GeminiAccount, refreshToken string) (geminiRefreshResponse, error) {
 	tokenURI := account.TokenURI
 	if tokenURI == "" {
 		tokenURI = geminiTokenURI
@@ -408,7 +823,7 @@ func (g *GeminiProvider) refreshAccessToken(ctx context.Context, account GeminiA
 
 	form := url.Values{}
 	form.Set("client_id", account.ClientID)
-	form.Set("refresh_token", account.RefreshToken)
+	form.Set("refresh_token", refreshToken)
 	form.Set("grant_type", "refresh_token")
 	if account.ClientSecret != "" {
 		form.Set("client_secret", account.ClientSecret)
@@ -416,46 +831,41 @@ func (g *GeminiProvider) refreshAccessToken(ctx context.Context, account GeminiA
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
 	if err != nil {
-		return "", fmt.Errorf("failed to create token refresh request: %w", err)
+		return geminiRefreshResponse{}, fmt.Errorf("failed to create token refresh request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := g.client.Do(req)
 	if err != nil {
 		debugf("Gemini", "token refresh request failed: %v", err)
-		return "", fmt.Errorf("token refresh request failed: %w", err)
+		return geminiRefreshResponse{}, fmt.Errorf("token refresh request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		debugf("Gemini", "failed to read token refresh response: %v", err)
-		return "", fmt.Errorf("failed to read token refresh response: %w", err)
+		return geminiRefreshResponse{}, fmt.Errorf("failed to read token refresh response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		debugf("Gemini", "token refresh non-200 status=%d body=%q", resp.StatusCode, debugBody(body))
-		return "", fmt.Errorf("token refresh failed: status %d: %s", resp.StatusCode, TruncateBody(body, 200))
+		return geminiRefreshResponse{}, fmt.Errorf("token refresh failed: %w", APIStatusError{
+			StatusCode: resp.StatusCode,
+			Body:       TruncateBody(body, 200),
+		})
 	}
 
 	var refreshResp geminiRefreshResponse
 	if err := json.Unmarshal(body, &refreshResp); err != nil {
 		debugf("Gemini", "failed to parse token refresh response: %v body=%q", err, debugBody(body))
-		return "", fmt.Errorf("failed to parse token refresh response: %w", err)
+		return geminiRefreshResponse{}, fmt.Errorf("failed to parse token refresh response: %w", err)
 	}
 	if refreshResp.AccessToken == "" {
 		debugf("Gemini", "token refresh response missing access_token")
-		return "", fmt.Errorf("token refresh failed: empty access_token")
+		return geminiRefreshResponse{}, fmt.Errorf("token refresh failed: empty access_token")
 	}
-
-	if account.CredentialPath == "" {
-		return "", fmt.Errorf("credential path not available for refresh")
-	}
-	if err := updateGeminiCredentialFile(account.CredentialPath, refreshResp); err != nil {
-		return "", err
-	}
-
-	return refreshResp.AccessToken, nil
+	return refreshResp, nil
 }
 
 func updateGeminiCredentialFile(path string, refreshResp geminiRefreshResponse) error {
@@ -505,8 +915,99 @@ func (g *GeminiProvider) doQuotaRequest(ctx context.Context, account GeminiAccou
 	return body, resp.StatusCode, nil
 }
 
+// codeAssistInfo resolves the account's Code Assist project and tier via
+// loadCodeAssist, cached per account. Credentials from the Gemini CLI carry no
+// project, so quota requests rely on the discovered one. Failures are logged
+// and returned with empty info so the quota request can still be attempted.
+func (g *GeminiProvider) codeAssistInfo(ctx context.Context, account GeminiAccount, token string) (geminiCodeAssistInfo, error) {
+	key := account.CredentialPath
+	if key == "" {
+		key = account.Email
+	}
+	now := time.Now()
+	if info, ok := g.assist.get(key, now); ok {
+		return info, nil
+	}
+
+	resp, err := g.loadCodeAssist(ctx, account, token)
+	if err != nil {
+		debugf("Gemini", "loadCodeAssist failed for %s: %v", geminiProviderName(account), err)
+		return geminiCodeAssistInfo{}, err
+	}
+	info := geminiCodeAssistInfo{
+		Project:   resp.projectID(),
+		Tier:      geminiPlan(resp),
+		FetchedAt: now,
+	}
+	g.assist.put(key, info)
+	return info, nil
+}
+
+func (g *GeminiProvider) loadCodeAssist(ctx context.Context, account GeminiAccount, token string) (*geminiLoadCodeAssistResponse, error) {
+	payload := map[string]any{
+		"metadata": map[string]string{
+			"ideType":    "IDE_UNSPECIFIED",
+			"platform":   "PLATFORM_UNSPECIFIED",
+			"pluginType": "GEMINI",
+		},
+	}
+	if account.ProjectID != "" {
+		payload["cloudaicompanionProject"] = account.ProjectID
+	}
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+geminiLoadEndpoint, strings.NewReader(string(reqBody)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", UserAgent())
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, TruncateBody(body, 200))
+	}
+
+	var info geminiLoadCodeAssistResponse
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &info, nil
+}
+
+// geminiPlan derives a short tier name such as "standard" or "free" from the
+// current tier, falling back to the default allowed tier.
+func geminiPlan(info *geminiLoadCodeAssistResponse) string {
+	tier := info.CurrentTier
+	if tier == nil {
+		for i := range info.AllowedTiers {
+			if info.AllowedTiers[i].IsDefault {
+				tier = &info.AllowedTiers[i]
+				break
+			}
+		}
+	}
+	if tier == nil || tier.ID == "" {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(tier.ID), "-tier")
+}
+
 // bucketToRow converts a quota bucket to a UsageRow
-func (g *GeminiProvider) bucketToRow(email string, bucket geminiQuotaBucket) (UsageRow, error) {
+func (g *GeminiProvider) bucketToRow(providerName string, bucket geminiQuotaBucket) (UsageRow, error) {
 	// Parse reset time (ISO 8601) - use RFC3339Nano to accept fractional seconds
 	resetTime, err := time.Parse(time.RFC3339Nano, bucket.ResetTime)
 	if err != nil {
@@ -528,10 +1029,11 @@ func (g *GeminiProvider) bucketToRow(email string, bucket geminiQuotaBucket) (Us
 	usedPercent := (1.0 - remainingFraction) * 100.0
 
 	return UsageRow{
-		Provider:     fmt.Sprintf("Gemini (%s)", email),
+		Provider:     providerName,
 		Label:        bucket.ModelID,
 		UsagePercent: usedPercent,
 		ResetTime:    resetTime,
+		Blocked:      remainingFraction <= 0,
 		IsWarning:    false,
 	}, nil
 }
@@ -0,0 +1,22 @@
+package providers
+
+import "time"
+
+// geminiCodeAssistTTL is how long a discovered Code Assist project and tier
+// are reused before loadCodeAssist is called again.
+const geminiCodeAssistTTL = 24 * time.Hour
+
+// geminiCodeAssistInfo is the result of loadCodeAssist discovery for an account.
+type geminiCodeAssistInfo struct {
+	Project   string    `json:"project"`
+	Tier      string    `json:"tier"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+func (i geminiCodeAssistInfo) expiresAt() time.Time {
+	return i.FetchedAt.Add(geminiCodeAssistTTL)
+}
+
+// geminiCodeAssistCache remembers discovery results per account, persisted
+// to $XDG_CACHE_HOME/aim/gemini-code-assist.json by default.
+type geminiCodeAssistCache = persistedCache[geminiCodeAssistInfo]
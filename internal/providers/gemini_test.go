@@ -28,6 +28,11 @@ func TestGeminiProvider_FetchUsage_SingleAccount(t *testing.T) {
 		if r.Method != http.MethodPost {
 			t.Errorf("Expected POST, got %s", r.Method)
 		}
+		if r.URL.Path == geminiLoadEndpoint {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"allowedTiers": [{"id": "standard-tier", "name": "Gemini Code Assist", "isDefault": true}]}`))
+			return
+		}
 		if r.URL.Path != geminiEndpoint {
 			t.Errorf("Expected path %s, got %s", geminiEndpoint, r.URL.Path)
 		}
@@ -255,6 +260,11 @@ func TestGeminiProvider_FetchUsage_MultipleBuckets(t *testing.T) {
 func TestGeminiProvider_FetchUsage_MultipleAccounts(t *testing.T) {
 	callCount := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == geminiLoadEndpoint {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"currentTier": {"id": "standard-tier", "name": "Gemini Code Assist"}}`))
+			return
+		}
 		callCount++
 		resp := geminiQuotaResponse{
 			Buckets: []geminiQuotaBucket{
@@ -324,6 +334,11 @@ func TestGeminiProvider_FetchUsage_MultipleAccounts(t *testing.T) {
 	if !providers["Gemini (alice@test.com)"] || !providers["Gemini (bob@test.com)"] {
 		t.Errorf("Expected both accounts, got providers: %v", providers)
 	}
+	for _, row := range rows {
+		if row.Plan != "standard" {
+			t.Errorf("%s Plan = %q, want %q", row.Provider, row.Plan, "standard")
+		}
+	}
 }
 
 func TestGeminiProvider_FetchUsage_NoCreds(t *testing.T) {
@@ -353,7 +368,6 @@ func TestGeminiProvider_FetchUsage_NoCreds(t *testing.T) {
 	if row.Provider != "Gemini" {
 		t.Errorf("Provider = %q, want %q", row.Provider, "Gemini")
 	}
-	// Message now uses source.DisplayName() dynamically
 	if !strings.Contains(row.WarningMsg, "No valid credentials found for") {
 		t.Errorf("WarningMsg = %q, want prefix 'No valid credentials found for'", row.WarningMsg)
 	}
@@ -572,6 +586,75 @@ func TestGeminiProvider_FetchUsage_APIError(t *testing.T) {
 	}
 }
 
+func TestGeminiProvider_RefreshesTokenBeforeExpiry(t *testing.T) {
+	refreshCalls := 0
+	refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new-token","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer refreshServer.Close()
+
+	var quotaTokens []string
+	quotaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == geminiLoadEndpoint {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		quotaTokens = append(quotaTokens, r.Header.Get("Authorization"))
+		resp := geminiQuotaResponse{
+			Buckets: []geminiQuotaBucket{
+				{ModelID: "gemini-2.5-pro", TokenType: "REQUESTS", RemainingFraction: 0.5, ResetTime: "2025-10-22T16:01:15Z"},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer quotaServer.Close()
+
+	tmpDir := t.TempDir()
+	credDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	if err := os.MkdirAll(credDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cred := map[string]any{
+		"token": map[string]string{
+			"access_token":  "expiring-token",
+			"refresh_token": "refresh-token",
+			"client_id":     "client-id",
+			"client_secret": "client-secret",
+			"token_uri":     refreshServer.URL + "/token",
+			"expiry":        time.Now().Add(2 * time.Minute).Format(time.RFC3339),
+		},
+		"project_id": "proj",
+	}
+	data, _ := json.Marshal(cred)
+	if err := os.WriteFile(filepath.Join(credDir, "gemini-user@test.com-proj.json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &GeminiProvider{
+		homeDir: tmpDir,
+		baseURL: quotaServer.URL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].IsWarning {
+		t.Fatalf("Expected 1 data row, got %+v", rows)
+	}
+	if refreshCalls != 1 {
+		t.Errorf("Expected 1 refresh call, got %d", refreshCalls)
+	}
+	if len(quotaTokens) != 1 || quotaTokens[0] != "Bearer new-token" {
+		t.Errorf("Expected a single quota call with the refreshed token, got %v", quotaTokens)
+	}
+}
+
 func TestGeminiProvider_RefreshesTokenOn401(t *testing.T) {
 	refreshCalls := 0
 	refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -604,6 +687,10 @@ func TestGeminiProvider_RefreshesTokenOn401(t *testing.T) {
 
 	quotaCalls := 0
 	quotaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == geminiLoadEndpoint {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
 		quotaCalls++
 		if r.URL.Path != geminiEndpoint {
 			t.Errorf("Expected path %s, got %s", geminiEndpoint, r.URL.Path)
@@ -1070,7 +1157,7 @@ func TestGeminiLoadCredentials_UsesGlobalSource(t *testing.T) {
 			}
 
 			p := &GeminiProvider{homeDir: tmpDir}
-			accounts, _, _ := p.loadCredentials()
+			accounts, _ := p.loadCredentials()
 
 			if len(accounts) != tt.expectAccounts {
 				t.Errorf("expected %d accounts, got %d", tt.expectAccounts, len(accounts))
@@ -1086,6 +1173,47 @@ func TestGeminiLoadCredentials_UsesGlobalSource(t *testing.T) {
 	}
 }
 
+func TestGeminiLoadCredentials_DedupesByEmailAndProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	proxyDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	if err := os.MkdirAll(proxyDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"gemini-a@example.com-proj.json":  `{"token":{"access_token":"stale","expiry":"2026-01-01T00:00:00Z"},"project_id":"proj"}`,
+		"gemini-copy.json":                `{"token":{"access_token":"current","expiry":"2026-06-01T00:00:00Z"},"project_id":"proj","email":"A@example.com"}`,
+		"gemini-a@example.com-other.json": `{"token":{"access_token":"other"},"project_id":"other"}`,
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(proxyDir, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &GeminiProvider{homeDir: tmpDir}
+	accounts, warnings := p.loadCredentials()
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+
+	tokens := make(map[string]string)
+	for _, account := range accounts {
+		tokens[account.ProjectID] = account.Token
+	}
+	if len(accounts) != 2 || tokens["proj"] != "current" || tokens["other"] != "other" {
+		t.Errorf("expected one account per project using the newest token, got %+v", accounts)
+	}
+
+	// The same email under two projects must not share a row or metric name.
+	names := make(map[string]bool)
+	for _, account := range accounts {
+		names[geminiProviderName(account)] = true
+	}
+	if len(names) != 2 || !names["Gemini (A@example.com/proj)"] || !names["Gemini (a@example.com/other)"] {
+		t.Errorf("expected project-qualified names, got %v", names)
+	}
+}
+
 func TestGeminiRefreshAccessToken_NativeSkip(t *testing.T) {
 	p := &GeminiProvider{
 		homeDir: t.TempDir(),
@@ -1107,3 +1235,95 @@ func TestGeminiRefreshAccessToken_NativeSkip(t *testing.T) {
 		t.Errorf("error = %q, want message about re-authentication", err.Error())
 	}
 }
+
+func TestGeminiProvider_FetchUsage_DiscoversProjectForNativeCredentials(t *testing.T) {
+	loadCalls := 0
+	var quotaProjects []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == geminiLoadEndpoint {
+			loadCalls++
+			if strings.Contains(string(body), "cloudaicompanionProject") {
+				t.Errorf("loadCodeAssist should not send a project for native credentials: %s", body)
+			}
+			_, _ = w.Write([]byte(`{"currentTier": {"id": "free-tier"}, "cloudaicompanionProject": "discovered-proj"}`))
+			return
+		}
+		var req struct {
+			Project string `json:"project"`
+		}
+		_ = json.Unmarshal(body, &req)
+		quotaProjects = append(quotaProjects, req.Project)
+		_, _ = w.Write([]byte(`{"buckets": [{"modelId": "gemini-3.0-pro", "remainingFraction": 0.5, "resetTime": "2026-01-02T16:01:15Z"}]}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	geminiDir := filepath.Join(tmpDir, ".gemini")
+	if err := os.MkdirAll(geminiDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cred := `{"access_token": "ya29.native-token", "refresh_token": "1//refresh"}`
+	if err := os.WriteFile(filepath.Join(geminiDir, "oauth_creds.json"), []byte(cred), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cachePath := filepath.Join(tmpDir, "cache", "gemini-code-assist.json")
+
+	newProvider := func() *GeminiProvider {
+		return &GeminiProvider{
+			homeDir: tmpDir,
+			baseURL: server.URL,
+			client:  &http.Client{Timeout: 5 * time.Second},
+			assist:  geminiCodeAssistCache{path: cachePath},
+		}
+	}
+
+	provider := newProvider()
+	for i := 0; i < 2; i++ {
+		rows, err := provider.FetchUsage(context.Background())
+		if err != nil {
+			t.Fatalf("FetchUsage() error = %v", err)
+		}
+		if len(rows) != 1 || rows[0].IsWarning || rows[0].Plan != "free" {
+			t.Fatalf("unexpected rows: %+v", rows)
+		}
+	}
+
+	// A fresh provider reads the discovery result from the cache file.
+	if _, err := newProvider().FetchUsage(context.Background()); err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+
+	if loadCalls != 1 {
+		t.Errorf("expected 1 loadCodeAssist call, got %d", loadCalls)
+	}
+	for i, project := range quotaProjects {
+		if project != "discovered-proj" {
+			t.Errorf("quota request %d project = %q, want %q", i, project, "discovered-proj")
+		}
+	}
+}
+
+func TestGeminiCodeAssistCache_PutKeepsOtherProcessEntries(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache", "gemini-code-assist.json")
+	now := time.Now()
+
+	// Both caches load the (missing) file before either writes, as two aim
+	// processes started together would.
+	first := &geminiCodeAssistCache{path: cachePath}
+	second := &geminiCodeAssistCache{path: cachePath}
+	first.get("a", now)
+	second.get("b", now)
+
+	first.put("a", geminiCodeAssistInfo{Project: "proj-a", FetchedAt: now})
+	second.put("b", geminiCodeAssistInfo{Project: "proj-b", FetchedAt: now})
+
+	fresh := &geminiCodeAssistCache{path: cachePath}
+	for key, want := range map[string]string{"a": "proj-a", "b": "proj-b"} {
+		info, ok := fresh.get(key, now)
+		if !ok || info.Project != want {
+			t.Errorf("get(%q) = %+v, %v, want project %q", key, info, ok, want)
+		}
+	}
+}
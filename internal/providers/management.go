@@ -0,0 +1,178 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// managementAPIPath is the prefix of CLIProxyAPI's management endpoints.
+const managementAPIPath = "/v0/management"
+
+// managementListingTTL is how long an auth file listing is shared between
+// providers. It covers one run, in which every provider loads credentials
+// at once, and is shorter than the minimum watch interval so each refresh
+// lists again.
+const managementListingTTL = 5 * time.Second
+
+// managementClient lists and downloads auth files from a running CLIProxyAPI
+// through its management API, so aim can run on a different machine from
+// the proxy.
+type managementClient struct {
+	baseURL string
+	key     string
+	client  *http.Client
+	listing *managementListing
+}
+
+// managementListing is an auth file listing shared by the management
+// clients of every provider that talks to the same proxy, so a run lists
+// the proxy's files once rather than once per provider.
+type managementListing struct {
+	mu        sync.Mutex
+	files     []managementAuthFile
+	err       error
+	fetchedAt time.Time
+}
+
+// managementListings holds the shared listing for each proxy, keyed by base
+// URL and management key.
+var managementListings = struct {
+	sync.Mutex
+	byProxy map[string]*managementListing
+}{byProxy: make(map[string]*managementListing)}
+
+// sharedManagementListing returns the listing shared by clients of the proxy
+// at baseURL using key.
+func sharedManagementListing(baseURL, key string) *managementListing {
+	managementListings.Lock()
+	defer managementListings.Unlock()
+	id := baseURL + "\x00" + key
+	listing, ok := managementListings.byProxy[id]
+	if !ok {
+		listing = &managementListing{}
+		managementListings.byProxy[id] = listing
+	}
+	return listing
+}
+
+// managementAuthFile is one entry of the auth-files listing.
+type managementAuthFile struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// managementFile is a downloaded auth file.
+type managementFile struct {
+	Name     string
+	Location string // shown in place of a file path, e.g. "https://proxy:8317#codex-a.json"
+	Data     []byte
+}
+
+// newManagementClient returns a client for the management API configured in
+// opts, or nil when none is. client supplies the per-request timeout.
+func newManagementClient(opts Options, client *http.Client) *managementClient {
+	if opts.ManagementURL == "" {
+		return nil
+	}
+	baseURL := strings.TrimRight(opts.ManagementURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, managementAPIPath)
+	return &managementClient{
+		baseURL: baseURL,
+		key:     opts.ManagementKey,
+		client:  client,
+		listing: sharedManagementListing(baseURL, opts.ManagementKey),
+	}
+}
+
+// authFiles downloads every auth file of credential type typ, or whose name
+// matches pattern when the proxy does not report a type, sorted by name.
+func (m *managementClient) authFiles(ctx context.Context, typ, pattern string) ([]managementFile, error) {
+	listing, err := m.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []managementFile
+	for _, entry := range listing {
+		matched, _ := filepath.Match(pattern, entry.Name)
+		if entry.Type != typ && (entry.Type != "" || !matched) {
+			continue
+		}
+		data, err := m.get(ctx, managementAPIPath+"/auth-files/download?name="+url.QueryEscape(entry.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", entry.Name, err)
+		}
+		files = append(files, managementFile{
+			Name:     entry.Name,
+			Location: m.baseURL + "#" + entry.Name,
+			Data:     data,
+		})
+	}
+	return files, nil
+}
+
+// list returns the proxy's auth files sorted by name, reusing a listing, or
+// the error from fetching it, made within managementListingTTL.
+func (m *managementClient) list(ctx context.Context) ([]managementAuthFile, error) {
+	shared := m.listing
+	if shared == nil {
+		shared = &managementListing{}
+	}
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	if !shared.fetchedAt.IsZero() && time.Since(shared.fetchedAt) < managementListingTTL {
+		return shared.files, shared.err
+	}
+
+	var listing struct {
+		Files []managementAuthFile `json:"files"`
+	}
+	body, err := m.get(ctx, managementAPIPath+"/auth-files")
+	if err != nil && ctx.Err() != nil {
+		// Only this caller gave up; others may still list successfully.
+		return nil, err
+	}
+	if err == nil {
+		if err = json.Unmarshal(body, &listing); err != nil {
+			err = fmt.Errorf("failed to parse management API auth file list: %w", err)
+		}
+	}
+	sort.Slice(listing.Files, func(i, j int) bool { return listing.Files[i].Name < listing.Files[j].Name })
+
+	shared.files, shared.err, shared.fetchedAt = listing.Files, err, time.Now()
+	return shared.files, shared.err
+}
+
+func (m *managementClient) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create management API request: %w", err)
+	}
+	if m.key != "" {
+		req.Header.Set("Authorization", "Bearer "+m.key)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("management API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read management API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("management API: %w", APIStatusError{StatusCode: resp.StatusCode, Body: TruncateBody(body, 200)})
+	}
+	return body, nil
+}
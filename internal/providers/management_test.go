@@ -0,0 +1,183 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newManagementServer starts a stand-in CLIProxyAPI management API serving
+// files, keyed by name, to requests carrying key. types gives the type
+// reported for each name in the listing; names without one report none.
+func newManagementServer(t *testing.T, key string, files, types map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+key {
+			http.Error(w, `{"error":"invalid management key"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v0/management/auth-files":
+			var listing struct {
+				Files []managementAuthFile `json:"files"`
+			}
+			for name := range files {
+				listing.Files = append(listing.Files, managementAuthFile{Name: name, Type: types[name]})
+			}
+			_ = json.NewEncoder(w).Encode(listing)
+		case "/v0/management/auth-files/download":
+			data, ok := files[r.URL.Query().Get("name")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(data))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestManagementClient_AuthFiles(t *testing.T) {
+	server := newManagementServer(t, "secret", map[string]string{
+		"codex-b@example.com.json":  `{"access_token":"b"}`,
+		"codex-a@example.com.json":  `{"access_token":"a"}`,
+		"team-account.json":         `{"access_token":"team"}`,
+		"claude-c@example.com.json": `{"access_token":"c"}`,
+	}, map[string]string{
+		"codex-a@example.com.json":  "codex",
+		"team-account.json":         "codex",
+		"claude-c@example.com.json": "claude",
+	})
+
+	client := newManagementClient(Options{ManagementURL: server.URL + "/v0/management/", ManagementKey: "secret"}, server.Client())
+	files, err := client.authFiles(context.Background(), "codex", "codex-*.json")
+	if err != nil {
+		t.Fatalf("authFiles() error = %v", err)
+	}
+
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	want := "codex-a@example.com.json,codex-b@example.com.json,team-account.json"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("files = %s, want %s", got, want)
+	}
+	if files[0].Location != server.URL+"#codex-a@example.com.json" || string(files[0].Data) != `{"access_token":"a"}` {
+		t.Errorf("unexpected file %+v", files[0])
+	}
+}
+
+func TestManagementClient_SharesListingAcrossProviders(t *testing.T) {
+	management := newManagementServer(t, "secret", map[string]string{
+		"codex-a@example.com.json":  `{"access_token":"a"}`,
+		"claude-c@example.com.json": `{"access_token":"c"}`,
+	}, map[string]string{
+		"codex-a@example.com.json":  "codex",
+		"claude-c@example.com.json": "claude",
+	})
+	listCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v0/management/auth-files" {
+			listCalls++
+		}
+		management.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	opts := Options{ManagementURL: server.URL, ManagementKey: "secret"}
+	codex := newManagementClient(opts, server.Client())
+	claude := newManagementClient(opts, server.Client())
+	for _, tt := range []struct {
+		client *managementClient
+		typ    string
+	}{{codex, "codex"}, {claude, "claude"}} {
+		files, err := tt.client.authFiles(context.Background(), tt.typ, tt.typ+"-*.json")
+		if err != nil {
+			t.Fatalf("authFiles(%s) error = %v", tt.typ, err)
+		}
+		if len(files) != 1 {
+			t.Errorf("authFiles(%s) = %+v, want 1 file", tt.typ, files)
+		}
+	}
+	if listCalls != 1 {
+		t.Errorf("auth files listed %d times, want 1", listCalls)
+	}
+}
+
+func TestManagementClient_RejectedKey(t *testing.T) {
+	server := newManagementServer(t, "secret", nil, nil)
+
+	client := newManagementClient(Options{ManagementURL: server.URL, ManagementKey: "wrong"}, server.Client())
+	_, err := client.authFiles(context.Background(), "codex", "codex-*.json")
+	var statusErr APIStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 status error, got %v", err)
+	}
+}
+
+func TestCodexProvider_FetchUsage_ManagementSource(t *testing.T) {
+	management := newManagementServer(t, "secret", map[string]string{
+		"codex-user@example.com.json": `{"access_token":"remote-token","refresh_token":"rt","email":"user@example.com","account_id":"acct-1"}`,
+	}, map[string]string{"codex-user@example.com.json": "codex"})
+
+	usage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer remote-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp := codexAPIResponse{PlanType: "plus"}
+		resp.RateLimit = codexRateLimit{
+			PrimaryWindow: &codexWindow{UsedPercent: 25, LimitWindowSeconds: 18000, ResetAt: time.Now().Add(time.Hour).Unix()},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer usage.Close()
+
+	refreshCalls := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer tokenServer.Close()
+
+	opts := Options{ManagementURL: management.URL, ManagementKey: "secret"}
+	client := &http.Client{Timeout: 5 * time.Second}
+	provider := &CodexProvider{
+		homeDir:    t.TempDir(),
+		baseURL:    usage.URL,
+		refreshURL: tokenServer.URL,
+		client:     client,
+		management: newManagementClient(opts, client),
+	}
+
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) == 0 || rows[0].IsWarning {
+		t.Fatalf("expected usage rows, got %+v", rows)
+	}
+	for _, row := range rows {
+		if row.Provider != "Codex (user@example.com)" || row.Source != "management" {
+			t.Errorf("unexpected row %+v", row)
+		}
+	}
+
+	// The proxy owns the refresh token, so aim must never spend it.
+	results := provider.RefreshCredentials(context.Background(), true)
+	if len(results) != 1 || results[0].Status != RefreshSkipped {
+		t.Errorf("expected skipped refresh, got %+v", results)
+	}
+	if refreshCalls != 0 {
+		t.Errorf("token endpoint called %d times, want 0", refreshCalls)
+	}
+}
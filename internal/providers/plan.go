@@ -0,0 +1,8 @@
+package providers
+
+// setPlan fills in Plan on every row.
+func setPlan(rows []UsageRow, plan string) {
+	for i := range rows {
+		rows[i].Plan = plan
+	}
+}
@@ -0,0 +1,812 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	qwenDefaultBaseURL = "https://portal.qwen.ai/v1"
+	qwenRefreshURL     = "https://chat.qwen.ai/api/v1/oauth2/token"
+	qwenClientID       = "f0304373b74a44d2b584a3fb70ca9e56"
+	qwenProxyPattern   = "qwen-*.json"
+
+	// qwenDailyRequestLimit is the free Qwen OAuth allowance documented by
+	// Qwen Code. The API does not report usage against it.
+	qwenDailyRequestLimit = 2000
+)
+
+// qwenQuotaWindows are the request windows Qwen Code documents for Qwen
+// OAuth, shortest first: 60 requests per minute and 2000 per day.
+var qwenQuotaWindows = []struct {
+	length time.Duration
+	label  string
+}{
+	{time.Minute, "per-minute requests"},
+	{24 * time.Hour, "daily requests"},
+}
+
+// QwenAccount holds credentials for a single Qwen Code account
+type QwenAccount struct {
+	Email          string
+	Token          string
+	RefreshToken   string
+	ResourceURL    string // API host the account is served from, e.g. "portal.qwen.ai"
+	ExpiresAt      time.Time
+	CredentialPath string
+	LoadErr        string // Error message from loading credentials, if any
+	IsNative       bool   // true when loaded from ~/.qwen/ instead of proxy
+	IsRemote       bool   // true when loaded from the management API, which owns the refresh token
+}
+
+// qwenCredentials represents the JSON structure of CLIProxyAPI qwen-*.json files
+type qwenCredentials struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ResourceURL  string `json:"resource_url"`
+	Email        string `json:"email"`
+	Type         string `json:"type"`
+	Expired      string `json:"expired"`
+}
+
+// qwenNativeCredentials represents the JSON structure of ~/.qwen/oauth_creds.json
+type qwenNativeCredentials struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ResourceURL  string `json:"resource_url"`
+	ExpiryDate   int64  `json:"expiry_date"` // Unix milliseconds
+}
+
+// qwenErrorResponse is the OpenAI-style error body returned by the Qwen API.
+type qwenErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// qwenQuota is the request quota reported in rate limit headers.
+type qwenQuota struct {
+	Limit     int64
+	Remaining int64
+	ResetAt   time.Time
+	Window    time.Duration // documented window the reset falls in; zero if unknown
+}
+
+// QwenProvider implements the Provider interface for Qwen Code. The Qwen API
+// has no usage endpoint, so aim lists models, which does not count against
+// the quota, and reads any rate limit headers on the response. Without them
+// only the documented daily limit is shown.
+type QwenProvider struct {
+	homeDir    string
+	proxyDirs  []string
+	baseURL    string // overrides each account's resource URL when set
+	refreshURL string
+	client     *http.Client
+	refresh    refreshPolicy
+	source     SourceMode
+	management *managementClient
+}
+
+// QwenName is the name Qwen is registered under and the prefix of its rows.
+const QwenName = "Qwen"
+
+func init() {
+	Register(Registration{
+		Name:  QwenName,
+		Order: 3,
+		New:   func(opts Options) (Provider, error) { return NewQwenProviderWithOptions(opts) },
+	})
+}
+
+// NewQwenProvider creates a new QwenProvider with default settings
+func NewQwenProvider() (*QwenProvider, error) {
+	return NewQwenProviderWithOptions(Options{})
+}
+
+// NewQwenProviderWithOptions creates a new QwenProvider with the given options
+func NewQwenProviderWithOptions(opts Options) (*QwenProvider, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	timeout := 30 * time.Second
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+	}
+	return &QwenProvider{
+		homeDir:    homeDir,
+		proxyDirs:  opts.CredentialsDirs,
+		refreshURL: qwenRefreshURL,
+		client:     client,
+		refresh:    newRefreshPolicy(opts),
+		source:     opts.Source,
+		management: newManagementClient(opts, client),
+	}, nil
+}
+
+// Name returns the provider name
+func (q *QwenProvider) Name() string {
+	return QwenName
+}
+
+// FetchUsage fetches the request quota of every configured Qwen account
+func (q *QwenProvider) FetchUsage(ctx context.Context) ([]UsageRow, error) {
+	accounts, err := q.loadCredentialsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accounts) == 0 {
+		locations := credentialLocations(q.CredentialSources(), q.credentialDirs(), qwenProxyPattern, q.management, "~/.qwen/oauth_creds.json")
+		return []UsageRow{{
+			Provider:   QwenName,
+			IsWarning:  true,
+			WarningMsg: "No credential files found matching " + locations,
+		}}, nil
+	}
+
+	var rows []UsageRow
+	for _, account := range accounts {
+		accountRows, err := q.fetchAccountUsage(ctx, account)
+		if err != nil {
+			accountRows = []UsageRow{{
+				Provider:   qwenProviderName(account),
+				IsWarning:  true,
+				WarningMsg: err.Error(),
+			}}
+		}
+		setSource(accountRows, qwenAccountSource(account))
+		rows = append(rows, accountRows...)
+	}
+	return rows, nil
+}
+
+// RefreshCredentials refreshes proxy tokens that are due without fetching usage.
+func (q *QwenProvider) RefreshCredentials(ctx context.Context, force bool) []RefreshResult {
+	accounts, err := q.loadCredentialsContext(ctx)
+	if err != nil {
+		return []RefreshResult{{Provider: q.Name(), Status: RefreshFailed, Message: err.Error()}}
+	}
+
+	now := time.Now()
+	results := make([]RefreshResult, 0, len(accounts))
+	for _, account := range accounts {
+		results = append(results, refreshCredential(ctx, q.refresh, force, now, refreshCandidate{
+			providerName:    qwenProviderName(account),
+			loadErr:         account.LoadErr,
+			native:          account.IsNative,
+			remote:          account.IsRemote,
+			hasRefreshToken: account.RefreshToken != "",
+			expiresAt:       account.ExpiresAt,
+			refresh: func(ctx context.Context) (time.Time, bool, error) {
+				refreshed, reused, err := q.refreshOrReuseAccessToken(ctx, account)
+				if err != nil {
+					return time.Time{}, false, err
+				}
+				return refreshed.ExpiresAt, reused, nil
+			},
+		}))
+	}
+	return results
+}
+
+// DiagnoseCredentials reports on every Qwen credential file, proxy and native.
+func (q *QwenProvider) DiagnoseCredentials() []CredentialReport {
+	active := q.CredentialSources()
+	using := sourceNames(active, q.proxyDirs, q.management)
+	var reports []CredentialReport
+	var accounts []QwenAccount
+	for _, path := range globCredentialFiles(q.credentialDirs(), qwenProxyPattern) {
+		report, ok := newCredentialReport(q.Name(), path, SourceProxy, active, using)
+		if !ok {
+			continue
+		}
+		if report.Err == "" {
+			if account, err := q.loadCredentialFile(path); err != nil {
+				report.Err = err.Error()
+			} else {
+				report.setLoaded("qwen", account.Email, account.ExpiresAt, account.RefreshToken != "")
+				if report.Loaded() {
+					accounts = append(accounts, account)
+				}
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	if q.homeDir != "" {
+		nativePath := filepath.Join(q.homeDir, ".qwen", "oauth_creds.json")
+		if report, ok := newCredentialReport(q.Name(), nativePath, SourceNative, active, using); ok {
+			if report.Err == "" {
+				for _, account := range q.loadNativeCredentials() {
+					if account.LoadErr != "" {
+						report.Err = account.LoadErr
+					} else {
+						report.setLoaded("qwen", account.Email, account.ExpiresAt, account.RefreshToken != "")
+					}
+				}
+			}
+			reports = append(reports, report)
+		}
+	}
+
+	markDuplicates(reports, duplicateCredentials(accounts, qwenAccountIdentity, qwenCredentialRank))
+	return reports
+}
+
+// credentialDirs returns the CLIProxyAPI credential directories for this provider.
+func (q *QwenProvider) credentialDirs() []string {
+	return ProxyCredentialDirs(q.homeDir, q.proxyDirs)
+}
+
+// CredentialSources returns the credential sources loaded under the
+// configured source mode, proxy first.
+func (q *QwenProvider) CredentialSources() []CredentialSource {
+	return q.source.sources(q.credentialDirs(), qwenProxyPattern, q.management != nil)
+}
+
+// loadCredentials is loadCredentialsContext for callers without a context.
+func (q *QwenProvider) loadCredentials() ([]QwenAccount, error) {
+	return q.loadCredentialsContext(context.Background())
+}
+
+// loadCredentialsContext loads accounts from every selected credential source,
+// keeping one credential per email when several files hold it.
+func (q *QwenProvider) loadCredentialsContext(ctx context.Context) ([]QwenAccount, error) {
+	var accounts []QwenAccount
+	for _, source := range q.CredentialSources() {
+		switch source {
+		case SourceNative:
+			accounts = append(accounts, q.loadNativeCredentials()...)
+		case SourceManagement:
+			loaded, err := q.loadManagementCredentials(ctx)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, loaded...)
+		default:
+			accounts = append(accounts, q.loadProxyCredentials()...)
+		}
+	}
+	return dedupeAccounts(q.Name(), accounts, qwenAccountIdentity, qwenCredentialRank), nil
+}
+
+// loadProxyCredentials loads every qwen-*.json file in the proxy directories.
+func (q *QwenProvider) loadProxyCredentials() []QwenAccount {
+	matches := globCredentialFiles(q.credentialDirs(), qwenProxyPattern)
+
+	accounts := make([]QwenAccount, 0, len(matches))
+	for _, path := range matches {
+		account, err := q.loadCredentialFile(path)
+		if err != nil {
+			account = QwenAccount{
+				Email:          qwenEmailFromFilename(filepath.Base(path)),
+				CredentialPath: path,
+				LoadErr:        err.Error(),
+			}
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+// loadManagementCredentials loads qwen auth files from the CLIProxyAPI
+// management API. The proxy keeps refreshing these tokens, so aim drops the
+// refresh token rather than racing it for a rotation.
+func (q *QwenProvider) loadManagementCredentials(ctx context.Context) ([]QwenAccount, error) {
+	if q.management == nil {
+		return nil, fmt.Errorf("no CLIProxyAPI management URL configured")
+	}
+	files, err := q.management.authFiles(ctx, "qwen", qwenProxyPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]QwenAccount, 0, len(files))
+	for _, file := range files {
+		account, err := parseQwenCredentials(file.Data, file.Location, file.Name)
+		if err != nil {
+			account = QwenAccount{
+				Email:          qwenEmailFromFilename(file.Name),
+				CredentialPath: file.Location,
+				LoadErr:        err.Error(),
+			}
+		}
+		account.RefreshToken = ""
+		account.IsRemote = true
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// loadNativeCredentials loads credentials from ~/.qwen/oauth_creds.json. A
+// missing file yields no accounts; an unusable one yields an account with
+// LoadErr set.
+func (q *QwenProvider) loadNativeCredentials() []QwenAccount {
+	if q.homeDir == "" {
+		return nil
+	}
+
+	path := filepath.Join(q.homeDir, ".qwen", "oauth_creds.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var creds qwenNativeCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return []QwenAccount{{
+			CredentialPath: path,
+			IsNative:       true,
+			LoadErr:        fmt.Sprintf("failed to parse %s: %v", path, err),
+		}}
+	}
+	if creds.AccessToken == "" {
+		return []QwenAccount{{
+			CredentialPath: path,
+			IsNative:       true,
+			LoadErr:        fmt.Sprintf("no access token found in %s", path),
+		}}
+	}
+
+	account := QwenAccount{
+		Token:          creds.AccessToken,
+		RefreshToken:   creds.RefreshToken,
+		ResourceURL:    creds.ResourceURL,
+		CredentialPath: path,
+		IsNative:       true,
+	}
+	if creds.ExpiryDate > 0 {
+		account.ExpiresAt = time.UnixMilli(creds.ExpiryDate)
+	}
+	return []QwenAccount{account}
+}
+
+// loadCredentialFile loads a single qwen-*.json credential file
+func (q *QwenProvider) loadCredentialFile(path string) (QwenAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return QwenAccount{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	return parseQwenCredentials(data, path, filepath.Base(path))
+}
+
+// parseQwenCredentials parses a qwen-*.json auth file named filename.
+func parseQwenCredentials(data []byte, path, filename string) (QwenAccount, error) {
+	var creds qwenCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return QwenAccount{}, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if creds.Type != "" && creds.Type != "qwen" {
+		return QwenAccount{}, fmt.Errorf("credential type %q is not qwen", creds.Type)
+	}
+	if creds.AccessToken == "" {
+		return QwenAccount{}, fmt.Errorf("missing access_token")
+	}
+
+	email := creds.Email
+	if email == "" {
+		email = qwenEmailFromFilename(filename)
+	}
+	expiresAt, _ := parseCredentialTime(creds.Expired)
+
+	return QwenAccount{
+		Email:          email,
+		Token:          creds.AccessToken,
+		RefreshToken:   creds.RefreshToken,
+		ResourceURL:    creds.ResourceURL,
+		ExpiresAt:      expiresAt,
+		CredentialPath: path,
+	}, nil
+}
+
+// qwenEmailFromFilename extracts the email from a name like "qwen-user@example.com.json"
+func qwenEmailFromFilename(filename string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(filename, "qwen-"), ".json")
+}
+
+// fetchAccountUsage fetches the request quota for a single account
+func (q *QwenProvider) fetchAccountUsage(ctx context.Context, account QwenAccount) ([]UsageRow, error) {
+	if account.Token == "" {
+		if account.LoadErr != "" {
+			return nil, fmt.Errorf("failed to load credentials: %s", account.LoadErr)
+		}
+		return nil, fmt.Errorf("failed to load credentials")
+	}
+
+	// Refresh proxy tokens that are about to expire instead of waiting for a 401.
+	refreshed := false
+	if !account.IsNative && account.RefreshToken != "" && q.refresh.dueBefore(account.ExpiresAt, time.Now()) {
+		debugf(QwenName, "token for %s expires at %s, refreshing before quota request", qwenProviderName(account), account.ExpiresAt.Format(time.RFC3339))
+		refreshedAccount, err := q.refreshAccessToken(ctx, account)
+		switch {
+		case err == nil:
+			account = refreshedAccount
+		case time.Now().Before(account.ExpiresAt):
+			// The current token is still valid, so a token endpoint outage
+			// should not hide usage until it actually expires.
+			debugf(QwenName, "token refresh failed for %s, using current token until it expires: %v", qwenProviderName(account), err)
+		default:
+			debugf(QwenName, "token refresh failed for %s: %v", qwenProviderName(account), err)
+			return nil, err
+		}
+		refreshed = true
+	}
+
+	quota, err := q.fetchQuota(ctx, account)
+	if err != nil {
+		var statusErr APIStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized {
+			if account.IsNative {
+				return nil, fmt.Errorf("token expired. Re-authenticate with qwen to refresh")
+			}
+			if account.RefreshToken != "" && !refreshed && !q.refresh.disabled {
+				debugf(QwenName, "attempting token refresh after status=%d for %s", statusErr.StatusCode, qwenProviderName(account))
+				refreshedAccount, refreshErr := q.refreshAccessToken(ctx, account)
+				if refreshErr != nil {
+					debugf(QwenName, "token refresh failed for %s: %v", qwenProviderName(account), refreshErr)
+					return nil, refreshErr
+				}
+				account = refreshedAccount
+				quota, err = q.fetchQuota(ctx, account)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	providerName := qwenProviderName(account)
+	if quota == nil {
+		// Qwen does not report usage, so show the documented limit rather
+		// than a percentage aim cannot know.
+		return []UsageRow{{
+			Provider: providerName,
+			Label:    "daily requests",
+			Window:   24 * time.Hour,
+			Quota:    &Quota{Limit: qwenDailyRequestLimit, Unit: "requests"},
+		}}, nil
+	}
+	row := UsageRow{
+		Provider:  providerName,
+		Label:     "requests",
+		ResetTime: quota.ResetAt,
+		Window:    quota.Window,
+		Blocked:   quota.Remaining <= 0,
+		DebugInfo: fmt.Sprintf("requests:%d/%d", quota.Limit-quota.Remaining, quota.Limit),
+	}
+	for _, window := range qwenQuotaWindows {
+		if window.length == quota.Window {
+			row.Label = window.label
+		}
+	}
+	if quota.Limit > 0 {
+		row.UsagePercent = float64(quota.Limit-quota.Remaining) / float64(quota.Limit) * 100
+	} else {
+		row.UsagePercent = 100
+		row.DebugInfo = ""
+	}
+	return []UsageRow{row}, nil
+}
+
+// fetchQuota lists models with the account's token and returns the request
+// quota from the response's rate limit headers, or nil if there are none.
+// A 429 for an exhausted quota is reported as a quota with nothing remaining.
+func (q *QwenProvider) fetchQuota(ctx context.Context, account QwenAccount) (*qwenQuota, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, q.apiBaseURL(account)+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+account.Token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", UserAgent())
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	now := time.Now()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return parseQwenQuota(resp.Header, now), nil
+	case http.StatusTooManyRequests:
+		var apiErr qwenErrorResponse
+		_ = json.Unmarshal(body, &apiErr)
+		if apiErr.Error.Code == "insufficient_quota" || strings.Contains(strings.ToLower(apiErr.Error.Message), "quota") {
+			quota := parseQwenQuota(resp.Header, now)
+			if quota == nil {
+				quota = &qwenQuota{}
+			}
+			quota.Remaining = 0
+			if quota.ResetAt.IsZero() {
+				quota.ResetAt = parseRetryAfter(resp.Header.Get("Retry-After"), now)
+				quota.Window = qwenQuotaWindow(quota.ResetAt, now)
+			}
+			return quota, nil
+		}
+	}
+	debugf(QwenName, "models API non-200 status=%d body=%q", resp.StatusCode, debugBody(body))
+	return nil, APIStatusError{
+		StatusCode: resp.StatusCode,
+		Body:       TruncateBody(body, 200),
+	}
+}
+
+// parseQwenQuota reads the x-ratelimit-*-requests headers, or returns nil
+// when the limit is missing.
+func parseQwenQuota(header http.Header, now time.Time) *qwenQuota {
+	limit, err := strconv.ParseInt(strings.TrimSpace(header.Get("X-RateLimit-Limit-Requests")), 10, 64)
+	if err != nil || limit <= 0 {
+		return nil
+	}
+	remaining, err := strconv.ParseInt(strings.TrimSpace(header.Get("X-RateLimit-Remaining-Requests")), 10, 64)
+	if err != nil {
+		remaining = limit
+	}
+	resetAt := parseRateLimitReset(header.Get("X-RateLimit-Reset-Requests"), now)
+	return &qwenQuota{
+		Limit:     limit,
+		Remaining: max(0, min(remaining, limit)),
+		ResetAt:   resetAt,
+		Window:    qwenQuotaWindow(resetAt, now),
+	}
+}
+
+// qwenQuotaWindow returns the shortest documented Qwen window that a reset
+// at resetAt fits in, or zero when the reset is unknown or longer than all.
+func qwenQuotaWindow(resetAt, now time.Time) time.Duration {
+	if resetAt.IsZero() {
+		return 0
+	}
+	for _, window := range qwenQuotaWindows {
+		if !resetAt.After(now.Add(window.length)) {
+			return window.length
+		}
+	}
+	return 0
+}
+
+// parseRateLimitReset parses a reset header given as a duration ("6h0m0s")
+// or a number of seconds. It returns the zero time if the value is neither.
+func parseRateLimitReset(value string, now time.Time) time.Time {
+	value = strings.TrimSpace(value)
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(d)
+	}
+	return parseRetryAfter(value, now)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds. It returns
+// the zero time if the value is missing or not a positive number.
+func parseRetryAfter(value string, now time.Time) time.Time {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+// apiBaseURL returns the OpenAI-compatible API root for the account, built
+// from its resource URL the way Qwen Code does.
+func (q *QwenProvider) apiBaseURL(account QwenAccount) string {
+	if q.baseURL != "" {
+		return strings.TrimRight(q.baseURL, "/")
+	}
+	base := strings.TrimRight(account.ResourceURL, "/")
+	if base == "" {
+		return qwenDefaultBaseURL
+	}
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "https://" + base
+	}
+	if !strings.HasSuffix(base, "/v1") {
+		base += "/v1"
+	}
+	return base
+}
+
+// qwenAccountIdentity identifies the upstream account by email. Native
+// credentials do not record one and are never collapsed.
+func qwenAccountIdentity(account QwenAccount) string {
+	return strings.ToLower(account.Email)
+}
+
+func qwenCredentialRank(account QwenAccount) credentialRank {
+	return credentialRank{
+		path:      account.CredentialPath,
+		failed:    account.LoadErr != "",
+		native:    account.IsNative,
+		expiresAt: account.ExpiresAt,
+	}
+}
+
+func qwenAccountSource(account QwenAccount) CredentialSource {
+	if account.IsNative {
+		return SourceNative
+	}
+	if account.IsRemote {
+		return SourceManagement
+	}
+	return SourceProxy
+}
+
+func qwenProviderName(account QwenAccount) string {
+	switch {
+	case account.IsNative:
+		return QwenName + " (native)"
+	case account.Email != "":
+		return fmt.Sprintf("%s (%s)", QwenName, account.Email)
+	}
+	return QwenName
+}
+
+// refreshAccessToken refreshes the account's token and returns the account
+// with the new credentials, including the resource URL the token is served from.
+func (q *QwenProvider) refreshAccessToken(ctx context.Context, account QwenAccount) (QwenAccount, error) {
+	refreshed, _, err := q.refreshOrReuseAccessToken(ctx, account)
+	return refreshed, err
+}
+
+// refreshOrReuseAccessToken is refreshAccessToken that also reports whether
+// another process had already refreshed the token, in which case the token
+// it wrote is returned without calling the token endpoint.
+func (q *QwenProvider) refreshOrReuseAccessToken(ctx context.Context, account QwenAccount) (QwenAccount, bool, error) {
+	if account.IsNative {
+		return QwenAccount{}, false, fmt.Errorf("token expired. Re-authenticate with qwen to refresh")
+	}
+	if account.IsRemote {
+		return QwenAccount{}, false, fmt.Errorf("token expired. CLIProxyAPI refreshes management API credentials")
+	}
+	if account.RefreshToken == "" {
+		return QwenAccount{}, false, fmt.Errorf("refresh token not available")
+	}
+	if account.CredentialPath == "" {
+		return QwenAccount{}, false, fmt.Errorf("credential path not available for refresh")
+	}
+
+	unlock, err := lockCredentialFile(ctx, account.CredentialPath)
+	if err != nil {
+		return QwenAccount{}, false, err
+	}
+	defer unlock()
+
+	// Qwen rotates refresh tokens, so reuse a token another process already
+	// refreshed and otherwise refresh with the newest refresh token on disk.
+	if current, err := q.loadCredentialFile(account.CredentialPath); err == nil {
+		if current.Token != account.Token && !q.refresh.dueBefore(current.ExpiresAt, time.Now()) {
+			debugf(QwenName, "credentials for %s were refreshed concurrently, reusing newer token", qwenProviderName(account))
+			return account.withCredentials(current), true, nil
+		}
+		if current.RefreshToken != "" {
+			account.RefreshToken = current.RefreshToken
+		}
+	}
+
+	raw, err := q.requestTokenRefresh(ctx, account.RefreshToken)
+	if isInvalidGrant(err) {
+		// CLIProxyAPI does not take aim's lock, so it may have spent the
+		// refresh token after it was read above. Pick up what it wrote.
+		if current, loadErr := q.loadCredentialFile(account.CredentialPath); loadErr == nil &&
+			current.RefreshToken != "" && current.RefreshToken != account.RefreshToken {
+			if current.Token != account.Token && !q.refresh.dueBefore(current.ExpiresAt, time.Now()) {
+				debugf(QwenName, "refresh token for %s was rotated by another process, reusing newer token", qwenProviderName(account))
+				return account.withCredentials(current), true, nil
+			}
+			debugf(QwenName, "refresh token for %s was rotated by another process, retrying with the newer one", qwenProviderName(account))
+			raw, err = q.requestTokenRefresh(ctx, current.RefreshToken)
+		}
+	}
+	if err != nil {
+		return QwenAccount{}, false, err
+	}
+	account.Token = stringFromMap(raw, "access_token")
+	if refreshToken := stringFromMap(raw, "refresh_token"); refreshToken != "" {
+		account.RefreshToken = refreshToken
+	}
+	if resourceURL := stringFromMap(raw, "resource_url"); resourceURL != "" {
+		account.ResourceURL = resourceURL
+	}
+	now := time.Now()
+	expiresIn := int64FromMap(raw, "expires_in")
+	if expiresIn > 0 {
+		account.ExpiresAt = now.Add(time.Duration(expiresIn) * time.Second)
+	}
+
+	err = updateJSONCredentials(account.CredentialPath, func(creds map[string]any) error {
+		creds["access_token"] = account.Token
+		creds["refresh_token"] = account.RefreshToken
+		if account.ResourceURL != "" {
+			creds["resource_url"] = account.ResourceURL
+		}
+		creds["last_refresh"] = formatCredentialTime(now)
+		if expiresIn > 0 {
+			creds["expired"] = formatCredentialTime(account.ExpiresAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return QwenAccount{}, false, err
+	}
+	return account, false, nil
+}
+
+// withCredentials returns the account with the tokens, resource URL and
+// expiry of current, which another process wrote to the credential file.
+func (a QwenAccount) withCredentials(current QwenAccount) QwenAccount {
+	a.Token = current.Token
+	a.RefreshToken = current.RefreshToken
+	a.ExpiresAt = current.ExpiresAt
+	if current.ResourceURL != "" {
+		a.ResourceURL = current.ResourceURL
+	}
+	return a
+}
+
+// requestTokenRefresh exchanges refreshToken for new tokens, returning the
+// decoded response, which is guaranteed to hold an access token.
+func (q *QwenProvider) requestTokenRefresh(ctx context.Context, refreshToken string) (map[string]any, error) {
+	refreshURL := q.refreshURL
+	if refreshURL == "" {
+		refreshURL = qwenRefreshURL
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	form.Set("client_id", qwenClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, refreshURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		debugf(QwenName, "token refresh request failed: %v", err)
+		return nil, fmt.Errorf("token refresh request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token refresh response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		debugf(QwenName, "token refresh non-200 status=%d body=%q", resp.StatusCode, debugBody(body))
+		return nil, APIStatusError{
+			StatusCode: resp.StatusCode,
+			Body:       TruncateBody(body, 200),
+		}
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		debugf(QwenName, "failed to parse token refresh response: %v body=%q", err, debugBody(body))
+		return nil, fmt.Errorf("failed to parse token refresh response: %w", err)
+	}
+	if stringFromMap(raw, "access_token") == "" {
+		return nil, fmt.Errorf("token refresh failed: empty access_token")
+	}
+	return raw, nil
+}
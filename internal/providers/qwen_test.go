@@ -0,0 +1,403 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeQwenCredential writes a qwen-*.json proxy credential file into dir.
+func writeQwenCredential(t *testing.T, dir, email, contents string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "qwen-"+email+".json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestQwenProvider_Name(t *testing.T) {
+	provider := &QwenProvider{}
+	if got := provider.Name(); got != "Qwen" {
+		t.Errorf("Name() = %q, want %q", got, "Qwen")
+	}
+}
+
+func TestQwenProvider_FetchUsage_RateLimitHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer qwen-token" {
+			t.Errorf("unexpected Authorization: %s", auth)
+		}
+		w.Header().Set("X-RateLimit-Limit-Requests", "2000")
+		w.Header().Set("X-RateLimit-Remaining-Requests", "1500")
+		w.Header().Set("X-RateLimit-Reset-Requests", "6h0m0s")
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	writeQwenCredential(t, filepath.Join(tmpDir, ".cli-proxy-api"), "user@example.com",
+		`{"type":"qwen","access_token":"qwen-token","refresh_token":"rt","resource_url":"portal.qwen.ai","email":"user@example.com"}`)
+
+	provider := &QwenProvider{homeDir: tmpDir, baseURL: server.URL + "/v1", client: server.Client()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %+v", rows)
+	}
+	row := rows[0]
+	if row.Provider != "Qwen (user@example.com)" || row.Label != "daily requests" || row.Source != "proxy" {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if row.UsagePercent != 25 || row.Blocked || row.Window != 24*time.Hour {
+		t.Errorf("unexpected usage: %+v", row)
+	}
+	if until := time.Until(row.ResetTime); until < 5*time.Hour || until > 6*time.Hour {
+		t.Errorf("ResetTime = %v, want about 6h from now", row.ResetTime)
+	}
+}
+
+func TestQwenProvider_FetchUsage_QuotaExceeded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"code":"insufficient_quota","message":"Free allocated quota exceeded."}}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	writeQwenCredential(t, filepath.Join(tmpDir, ".cli-proxy-api"), "user@example.com",
+		`{"type":"qwen","access_token":"qwen-token","email":"user@example.com"}`)
+
+	provider := &QwenProvider{homeDir: tmpDir, baseURL: server.URL, client: server.Client()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].IsWarning {
+		t.Fatalf("expected one quota row, got %+v", rows)
+	}
+	if !rows[0].Blocked || rows[0].UsagePercent != 100 {
+		t.Errorf("expected exhausted quota to be blocked at 100%%, got %+v", rows[0])
+	}
+	if rows[0].ResetTime.IsZero() {
+		t.Error("expected reset time from Retry-After")
+	}
+}
+
+func TestQwenProvider_FetchUsage_NoQuotaHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	writeQwenCredential(t, filepath.Join(tmpDir, ".cli-proxy-api"), "user@example.com",
+		`{"type":"qwen","access_token":"qwen-token"}`)
+
+	provider := &QwenProvider{homeDir: tmpDir, baseURL: server.URL, client: server.Client()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].IsWarning {
+		t.Fatalf("expected a quota row, got %+v", rows)
+	}
+	row := rows[0]
+	if row.HasUsagePercent() || row.Quota == nil || row.Quota.Limit != qwenDailyRequestLimit {
+		t.Errorf("expected the documented limit with unknown usage, got %+v", row)
+	}
+	if row.Label != "daily requests" || row.Window != 24*time.Hour {
+		t.Errorf("unexpected window: %+v", row)
+	}
+	if row.Provider != "Qwen (user@example.com)" {
+		t.Errorf("expected email from filename, got %q", row.Provider)
+	}
+}
+
+func TestQwenProvider_FetchUsage_WindowFromReset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit-Requests", "60")
+		w.Header().Set("X-RateLimit-Remaining-Requests", "45")
+		w.Header().Set("X-RateLimit-Reset-Requests", "20s")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	writeQwenCredential(t, filepath.Join(tmpDir, ".cli-proxy-api"), "user@example.com",
+		`{"type":"qwen","access_token":"qwen-token"}`)
+
+	provider := &QwenProvider{homeDir: tmpDir, baseURL: server.URL, client: server.Client()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Label != "per-minute requests" || rows[0].Window != time.Minute || rows[0].UsagePercent != 25 {
+		t.Errorf("expected a per-minute quota at 25%%, got %+v", rows)
+	}
+}
+
+func TestQwenProvider_FetchUsage_RefreshesOn401(t *testing.T) {
+	var refreshCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/token":
+			refreshCalls++
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "old-refresh" ||
+				r.Form.Get("client_id") != qwenClientID {
+				t.Errorf("unexpected refresh form: %v", r.Form)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  "new-token",
+				"refresh_token": "new-refresh",
+				"resource_url":  "portal.qwen.ai",
+				"expires_in":    3600,
+			})
+		case "/models":
+			if r.Header.Get("Authorization") != "Bearer new-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":{"code":"invalid_api_key"}}`))
+				return
+			}
+			w.Header().Set("X-RateLimit-Limit-Requests", "2000")
+			w.Header().Set("X-RateLimit-Remaining-Requests", "2000")
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	path := writeQwenCredential(t, filepath.Join(tmpDir, ".cli-proxy-api"), "user@example.com",
+		`{"type":"qwen","access_token":"old-token","refresh_token":"old-refresh","email":"user@example.com"}`)
+
+	provider := &QwenProvider{
+		homeDir:    tmpDir,
+		baseURL:    server.URL,
+		refreshURL: server.URL + "/oauth2/token",
+		client:     server.Client(),
+	}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if refreshCalls != 1 {
+		t.Errorf("expected 1 refresh, got %d", refreshCalls)
+	}
+	if len(rows) != 1 || rows[0].IsWarning || rows[0].UsagePercent != 0 {
+		t.Fatalf("expected quota row after refresh, got %+v", rows)
+	}
+
+	updated, err := provider.loadCredentialFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Token != "new-token" || updated.RefreshToken != "new-refresh" || updated.ResourceURL != "portal.qwen.ai" {
+		t.Errorf("credential file not updated: %+v", updated)
+	}
+	if until := time.Until(updated.ExpiresAt); until < 50*time.Minute || until > time.Hour {
+		t.Errorf("ExpiresAt = %v, want about 1h from now", updated.ExpiresAt)
+	}
+}
+
+func TestQwenProvider_FetchUsage_UsesRefreshedResourceURL(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer new-token" {
+			t.Errorf("unexpected request: %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "new-token",
+			"resource_url": api.URL,
+			"expires_in":   3600,
+		})
+	}))
+	defer auth.Close()
+
+	tmpDir := t.TempDir()
+	expired := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	writeQwenCredential(t, filepath.Join(tmpDir, ".cli-proxy-api"), "user@example.com",
+		`{"type":"qwen","access_token":"old-token","refresh_token":"rt","resource_url":"`+auth.URL+`","expired":"`+expired+`"}`)
+
+	provider := &QwenProvider{homeDir: tmpDir, refreshURL: auth.URL, client: &http.Client{Timeout: 5 * time.Second}}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].IsWarning {
+		t.Errorf("expected a quota row from the refreshed resource URL, got %+v", rows)
+	}
+}
+
+func TestQwenProvider_FetchUsage_RefreshFailureKeepsValidToken(t *testing.T) {
+	var quotaTokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth2/token" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		quotaTokens = append(quotaTokens, r.Header.Get("Authorization"))
+		w.Header().Set("X-RateLimit-Limit-Requests", "2000")
+		w.Header().Set("X-RateLimit-Remaining-Requests", "1000")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	expiresSoon := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	writeQwenCredential(t, filepath.Join(tmpDir, ".cli-proxy-api"), "user@example.com",
+		`{"type":"qwen","access_token":"old-token","refresh_token":"rt","email":"user@example.com","expired":"`+expiresSoon+`"}`)
+
+	provider := &QwenProvider{
+		homeDir:    tmpDir,
+		baseURL:    server.URL,
+		refreshURL: server.URL + "/oauth2/token",
+		client:     server.Client(),
+	}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].IsWarning {
+		t.Fatalf("expected a quota row despite the refresh failure, got %+v", rows)
+	}
+	if len(quotaTokens) != 1 || quotaTokens[0] != "Bearer old-token" {
+		t.Errorf("expected the current token to be used, got %v", quotaTokens)
+	}
+}
+
+func TestQwenProvider_FetchUsage_NativeUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	qwenDir := filepath.Join(tmpDir, ".qwen")
+	if err := os.MkdirAll(qwenDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	native := `{"access_token":"native-token","refresh_token":"rt","resource_url":"portal.qwen.ai","expiry_date":1893456000000}`
+	if err := os.WriteFile(filepath.Join(qwenDir, "oauth_creds.json"), []byte(native), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &QwenProvider{homeDir: tmpDir, baseURL: server.URL, client: server.Client()}
+	accounts, err := provider.loadCredentials()
+	if err != nil {
+		t.Fatalf("loadCredentials() error = %v", err)
+	}
+	if len(accounts) != 1 || !accounts[0].IsNative || !accounts[0].ExpiresAt.Equal(time.Unix(1893456000, 0)) {
+		t.Fatalf("unexpected native accounts: %+v", accounts)
+	}
+
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Provider != "Qwen (native)" || rows[0].Source != "native" ||
+		!strings.Contains(rows[0].WarningMsg, "Re-authenticate with qwen") {
+		t.Errorf("expected re-authenticate warning for native account, got %+v", rows)
+	}
+}
+
+func TestQwenProvider_FetchUsage_NoCredentials(t *testing.T) {
+	provider := &QwenProvider{homeDir: t.TempDir()}
+	rows, err := provider.FetchUsage(context.Background())
+	if err != nil {
+		t.Fatalf("FetchUsage() error = %v", err)
+	}
+	if len(rows) != 1 || !rows[0].IsWarning || !strings.Contains(rows[0].WarningMsg, "~/.qwen/oauth_creds.json") {
+		t.Errorf("expected missing credentials warning, got %+v", rows)
+	}
+}
+
+func TestQwenLoadCredentials_SkipsOtherTypes(t *testing.T) {
+	tmpDir := t.TempDir()
+	proxyDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	writeQwenCredential(t, proxyDir, "a@example.com", `{"type":"qwen","access_token":"tok","email":"a@example.com"}`)
+	writeQwenCredential(t, proxyDir, "b@example.com", `{"type":"codex","access_token":"tok"}`)
+
+	provider := &QwenProvider{homeDir: tmpDir}
+	accounts, err := provider.loadCredentials()
+	if err != nil {
+		t.Fatalf("loadCredentials() error = %v", err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %+v", accounts)
+	}
+	if accounts[0].LoadErr != "" || accounts[1].LoadErr == "" || accounts[1].Email != "b@example.com" {
+		t.Errorf("expected the codex file to fail to load, got %+v", accounts)
+	}
+}
+
+func TestQwenProvider_RefreshCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "new-token", "expires_in": 7200})
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	proxyDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	expired := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	valid := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	writeQwenCredential(t, proxyDir, "due@example.com",
+		`{"type":"qwen","access_token":"old","refresh_token":"rt","email":"due@example.com","expired":"`+expired+`"}`)
+	writeQwenCredential(t, proxyDir, "fresh@example.com",
+		`{"type":"qwen","access_token":"tok","refresh_token":"rt","email":"fresh@example.com","expired":"`+valid+`"}`)
+
+	provider := &QwenProvider{homeDir: tmpDir, refreshURL: server.URL, client: server.Client()}
+	results := provider.RefreshCredentials(context.Background(), false)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	if results[0].Provider != "Qwen (due@example.com)" || results[0].Status != RefreshRefreshed {
+		t.Errorf("expected due token refreshed, got %+v", results[0])
+	}
+	if until := time.Until(results[0].ExpiresAt); until < time.Hour || until > 2*time.Hour {
+		t.Errorf("ExpiresAt = %v, want about 2h from now", results[0].ExpiresAt)
+	}
+	if results[1].Status != RefreshValid {
+		t.Errorf("expected fresh token left alone, got %+v", results[1])
+	}
+}
+
+func TestQwenAPIBaseURL(t *testing.T) {
+	tests := []struct {
+		resourceURL string
+		want        string
+	}{
+		{"", qwenDefaultBaseURL},
+		{"portal.qwen.ai", "https://portal.qwen.ai/v1"},
+		{"https://dashscope.aliyuncs.com/compatible-mode/v1/", "https://dashscope.aliyuncs.com/compatible-mode/v1"},
+	}
+	provider := &QwenProvider{}
+	for _, tt := range tests {
+		if got := provider.apiBaseURL(QwenAccount{ResourceURL: tt.resourceURL}); got != tt.want {
+			t.Errorf("apiBaseURL(%q) = %q, want %q", tt.resourceURL, got, tt.want)
+		}
+	}
+}
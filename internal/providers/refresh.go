@@ -0,0 +1,85 @@
+package providers
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshStatus is the outcome of refreshing one account's credentials.
+type RefreshStatus string
+
+const (
+	RefreshRefreshed RefreshStatus = "refreshed" // a new access token was written
+	RefreshReused    RefreshStatus = "reused"    // another process had already refreshed the token
+	RefreshValid     RefreshStatus = "valid"     // the token is not due for refresh
+	RefreshSkipped   RefreshStatus = "skipped"   // the credentials cannot be refreshed by aim
+	RefreshFailed    RefreshStatus = "failed"    // loading or refreshing the credentials failed
+)
+
+// RefreshResult reports what happened to one account during a refresh run.
+type RefreshResult struct {
+	Provider  string        // e.g. "Codex (user@example.com)"
+	Status    RefreshStatus // outcome of the run
+	ExpiresAt time.Time     // token expiry after the run; zero if unknown
+	Message   string        // reason for skipped or failed accounts
+}
+
+// Refresher is implemented by providers that can refresh stored credentials
+// without fetching usage.
+type Refresher interface {
+	// RefreshCredentials refreshes every account whose token is due under the
+	// provider's refresh policy, or every refreshable account when force is set.
+	RefreshCredentials(ctx context.Context, force bool) []RefreshResult
+}
+
+// refreshCandidate describes one loaded account for refreshCredential.
+type refreshCandidate struct {
+	providerName    string
+	loadErr         string
+	native          bool
+	remote          bool // loaded from the management API
+	hasRefreshToken bool
+	expiresAt       time.Time
+	// refresh refreshes the token and returns the new expiry (zero if
+	// unknown), and whether another process had already refreshed it.
+	refresh func(ctx context.Context) (time.Time, bool, error)
+}
+
+// refreshCredential applies policy to a single account, refreshing it when
+// it is due or force is set.
+func refreshCredential(ctx context.Context, policy refreshPolicy, force bool, now time.Time, c refreshCandidate) RefreshResult {
+	result := RefreshResult{Provider: c.providerName, ExpiresAt: c.expiresAt}
+	switch {
+	case c.loadErr != "":
+		result.Status = RefreshFailed
+		result.Message = "failed to load credentials: " + c.loadErr
+	case c.native:
+		result.Status = RefreshSkipped
+		result.Message = "native credentials are refreshed by their CLI"
+	case c.remote:
+		result.Status = RefreshSkipped
+		result.Message = "management API credentials are refreshed by CLIProxyAPI"
+	case !c.hasRefreshToken:
+		result.Status = RefreshSkipped
+		result.Message = "no refresh token"
+	case !force && !policy.dueBefore(c.expiresAt, now):
+		result.Status = RefreshValid
+		if c.expiresAt.IsZero() {
+			result.Message = "expiry unknown"
+		}
+	default:
+		expiresAt, reused, err := c.refresh(ctx)
+		if err != nil {
+			result.Status = RefreshFailed
+			result.Message = err.Error()
+			return result
+		}
+		result.Status = RefreshRefreshed
+		if reused {
+			result.Status = RefreshReused
+			result.Message = "refreshed by another process"
+		}
+		result.ExpiresAt = expiresAt
+	}
+	return result
+}
@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRefreshCredential_Statuses(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	newExpiry := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		candidate   refreshCandidate
+		force       bool
+		refreshErr  error
+		reused      bool
+		wantStatus  RefreshStatus
+		wantCalled  bool
+		wantExpires time.Time
+	}{
+		{
+			name:       "load error",
+			candidate:  refreshCandidate{loadErr: "bad json", hasRefreshToken: true},
+			wantStatus: RefreshFailed,
+		},
+		{
+			name:        "native",
+			candidate:   refreshCandidate{native: true, hasRefreshToken: true, expiresAt: now.Add(-time.Hour)},
+			wantStatus:  RefreshSkipped,
+			wantExpires: now.Add(-time.Hour),
+		},
+		{
+			name:        "management API",
+			candidate:   refreshCandidate{remote: true, expiresAt: now.Add(-time.Hour)},
+			force:       true,
+			wantStatus:  RefreshSkipped,
+			wantExpires: now.Add(-time.Hour),
+		},
+		{
+			name:        "no refresh token",
+			candidate:   refreshCandidate{expiresAt: now.Add(-time.Hour)},
+			wantStatus:  RefreshSkipped,
+			wantExpires: now.Add(-time.Hour),
+		},
+		{
+			name:        "not due",
+			candidate:   refreshCandidate{hasRefreshToken: true, expiresAt: now.Add(time.Hour)},
+			wantStatus:  RefreshValid,
+			wantExpires: now.Add(time.Hour),
+		},
+		{
+			name:       "unknown expiry",
+			candidate:  refreshCandidate{hasRefreshToken: true},
+			wantStatus: RefreshValid,
+		},
+		{
+			name:        "forced",
+			candidate:   refreshCandidate{hasRefreshToken: true, expiresAt: now.Add(time.Hour)},
+			force:       true,
+			wantStatus:  RefreshRefreshed,
+			wantCalled:  true,
+			wantExpires: newExpiry,
+		},
+		{
+			name:        "due",
+			candidate:   refreshCandidate{hasRefreshToken: true, expiresAt: now.Add(time.Minute)},
+			wantStatus:  RefreshRefreshed,
+			wantCalled:  true,
+			wantExpires: newExpiry,
+		},
+		{
+			name:        "refreshed by another process",
+			candidate:   refreshCandidate{hasRefreshToken: true, expiresAt: now.Add(time.Minute)},
+			reused:      true,
+			wantStatus:  RefreshReused,
+			wantCalled:  true,
+			wantExpires: newExpiry,
+		},
+		{
+			name:       "refresh error",
+			candidate:  refreshCandidate{hasRefreshToken: true, expiresAt: now.Add(-time.Minute)},
+			refreshErr: errors.New("invalid_grant"),
+			wantStatus: RefreshFailed,
+			wantCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			tt.candidate.refresh = func(ctx context.Context) (time.Time, bool, error) {
+				called = true
+				if tt.refreshErr != nil {
+					return time.Time{}, false, tt.refreshErr
+				}
+				return newExpiry, tt.reused, nil
+			}
+
+			got := refreshCredential(context.Background(), refreshPolicy{}, tt.force, now, tt.candidate)
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q (message %q)", got.Status, tt.wantStatus, got.Message)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("refresh called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantStatus != RefreshFailed && !got.ExpiresAt.Equal(tt.wantExpires) {
+				t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, tt.wantExpires)
+			}
+			if (tt.wantStatus == RefreshFailed || tt.wantStatus == RefreshSkipped) && got.Message == "" {
+				t.Error("expected a message explaining the status")
+			}
+		})
+	}
+}
+
+func TestCodexProvider_RefreshCredentials(t *testing.T) {
+	refreshCalls := 0
+	refreshServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new-token","expires_in":3600}`))
+	}))
+	defer refreshServer.Close()
+
+	tmpDir := t.TempDir()
+	credDir := filepath.Join(tmpDir, ".cli-proxy-api")
+	if err := os.MkdirAll(credDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"codex-due@example.com.json": fmt.Sprintf(`{"access_token": "a", "refresh_token": "r1", "email": "due@example.com", "expired": %q}`,
+			time.Now().Add(-time.Minute).Format(time.RFC3339)),
+		"codex-fresh@example.com.json": fmt.Sprintf(`{"access_token": "b", "refresh_token": "r2", "email": "fresh@example.com", "expired": %q}`,
+			time.Now().Add(time.Hour).Format(time.RFC3339)),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(credDir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := &CodexProvider{
+		homeDir:    tmpDir,
+		refreshURL: refreshServer.URL,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+
+	results := provider.RefreshCredentials(context.Background(), false)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	want := map[string]RefreshStatus{
+		"Codex (due@example.com)":   RefreshRefreshed,
+		"Codex (fresh@example.com)": RefreshValid,
+	}
+	for _, result := range results {
+		if result.Status != want[result.Provider] {
+			t.Errorf("%s: Status = %q, want %q (message %q)", result.Provider, result.Status, want[result.Provider], result.Message)
+		}
+		if result.Status == RefreshRefreshed && time.Until(result.ExpiresAt) < 30*time.Minute {
+			t.Errorf("%s: expected the new expiry, got %v", result.Provider, result.ExpiresAt)
+		}
+	}
+	if refreshCalls != 1 {
+		t.Errorf("expected 1 refresh call, got %d", refreshCalls)
+	}
+}
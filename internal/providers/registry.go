@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registration describes a provider known to aim. Providers register
+// themselves from an init function in their own file, so adding a provider
+// does not require changes elsewhere.
+type Registration struct {
+	// Name is the display name and row prefix, e.g. "Codex".
+	Name string
+	// Order is the provider's position in output; lower sorts first.
+	Order int
+	// New constructs the provider.
+	New func(Options) (Provider, error)
+}
+
+var registry = struct {
+	sync.RWMutex
+	byName map[string]Registration
+}{byName: make(map[string]Registration)}
+
+// Register adds a provider to the registry. It panics if the name is empty,
+// the factory is nil, or the name is already registered.
+func Register(r Registration) {
+	if r.Name == "" || r.New == nil {
+		panic("providers: Register requires a name and factory")
+	}
+	key := strings.ToLower(r.Name)
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, dup := registry.byName[key]; dup {
+		panic("providers: Register called twice for " + r.Name)
+	}
+	registry.byName[key] = r
+}
+
+// Registered returns all registered providers ordered by Order, then Name.
+func Registered() []Registration {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	list := make([]Registration, 0, len(registry.byName))
+	for _, r := range registry.byName {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Order != list[j].Order {
+			return list[i].Order < list[j].Order
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Lookup finds a registered provider by name (case-insensitive).
+func Lookup(name string) (Registration, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	r, ok := registry.byName[strings.ToLower(strings.TrimSpace(name))]
+	return r, ok
+}
+
+// ParseProviderList parses a comma-separated list of provider names, returning
+// their canonical names. Unknown names are an error.
+func ParseProviderList(value string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		r, ok := Lookup(part)
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %q (known: %s)", part, strings.Join(registeredNames(), ", "))
+		}
+		if !seen[r.Name] {
+			seen[r.Name] = true
+			names = append(names, r.Name)
+		}
+	}
+	return names, nil
+}
+
+func registeredNames() []string {
+	list := Registered()
+	names := make([]string, 0, len(list))
+	for _, r := range list {
+		names = append(names, r.Name)
+	}
+	return names
+}
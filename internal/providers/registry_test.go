@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistered_BuiltinsInOrder(t *testing.T) {
+	var names []string
+	for _, r := range Registered() {
+		names = append(names, r.Name)
+	}
+	got := strings.Join(names, ",")
+	if got != "Claude,Codex,Gemini,Qwen,Copilot" {
+		t.Errorf("Registered() = %s, want Claude,Codex,Gemini,Qwen,Copilot", got)
+	}
+}
+
+func TestLookup_CaseInsensitive(t *testing.T) {
+	r, ok := Lookup(" codex ")
+	if !ok || r.Name != "Codex" {
+		t.Fatalf("Lookup(codex) = %+v, %v", r, ok)
+	}
+	if _, ok := Lookup("unknown"); ok {
+		t.Error("Lookup(unknown) should fail")
+	}
+}
+
+func TestParseProviderList(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "", "", false},
+		{"single", "claude", "Claude", false},
+		{"multiple with spaces and dupes", "gemini, Codex,gemini", "Gemini,Codex", false},
+		{"unknown", "claude,openai", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProviderList(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProviderList(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("ParseProviderList(%q) = %v, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister_DuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for duplicate registration")
+		}
+	}()
+	Register(Registration{
+		Name: "claude",
+		New:  func(Options) (Provider, error) { return nil, nil },
+	})
+}
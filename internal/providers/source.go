@@ -1,7 +1,9 @@
 package providers
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 )
 
 // CredentialSource indicates where credentials are loaded from
@@ -12,29 +14,35 @@ const (
 	SourceProxy CredentialSource = iota
 	// SourceNative indicates credentials from native CLI directories
 	SourceNative
+	// SourceManagement indicates credentials from a CLIProxyAPI management API
+	SourceManagement
 )
 
-// DetectCredentialSource checks if ANY provider has creds in ~/.cli-proxy-api/
-// Returns SourceProxy if any found, SourceNative if empty or homeDir is invalid
-func DetectCredentialSource(homeDir string) CredentialSource {
+// ProxyCredentialDir returns the CLIProxyAPI credential directory: override
+// when set, otherwise ~/.cli-proxy-api. Returns "" when neither is usable.
+func ProxyCredentialDir(homeDir, override string) string {
+	if override != "" {
+		return override
+	}
 	// Guard against empty homeDir (e.g., when os.UserHomeDir() fails in CI)
 	// to avoid scanning current directory and producing misleading results
 	if homeDir == "" {
-		return SourceNative
+		return ""
 	}
+	return filepath.Join(homeDir, ".cli-proxy-api")
+}
 
-	patterns := []string{
-		filepath.Join(homeDir, ".cli-proxy-api", "claude-*.json"),
-		filepath.Join(homeDir, ".cli-proxy-api", "codex-*.json"),
-		filepath.Join(homeDir, ".cli-proxy-api", "gemini-*.json"),
+// ProxyCredentialDirs returns the CLIProxyAPI credential directories:
+// overrides when there are any, otherwise ~/.cli-proxy-api. Returns nil when
+// neither is usable.
+func ProxyCredentialDirs(homeDir string, overrides []string) []string {
+	if len(overrides) > 0 {
+		return overrides
 	}
-	for _, pattern := range patterns {
-		matches, _ := filepath.Glob(pattern)
-		if len(matches) > 0 {
-			return SourceProxy
-		}
+	if dir := ProxyCredentialDir(homeDir, ""); dir != "" {
+		return []string{dir}
 	}
-	return SourceNative
+	return nil
 }
 
 // DisplayName returns human-readable source for UI
@@ -44,6 +52,143 @@ func (s CredentialSource) DisplayName() string {
 		return "~/.cli-proxy-api/"
 	case SourceNative:
 		return "native CLI directories"
+	case SourceManagement:
+		return "CLIProxyAPI management API"
 	}
 	return "unknown"
 }
+
+// DescribeSources names each source for messages, showing the configured
+// proxy directories and management URL instead of the generic names.
+func DescribeSources(sources []CredentialSource, proxyDirs []string, managementURL string) []string {
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.DisplayName()
+		if source == SourceProxy && len(proxyDirs) > 0 {
+			names[i] = strings.Join(proxyDirs, ", ")
+		}
+		if source == SourceManagement && managementURL != "" {
+			names[i] = managementURL
+		}
+	}
+	return names
+}
+
+// sourceNames is DescribeSources for a provider's own proxy directory
+// overrides and management client.
+func sourceNames(sources []CredentialSource, proxyDirs []string, management *managementClient) []string {
+	managementURL := ""
+	if management != nil {
+		managementURL = management.baseURL
+	}
+	return DescribeSources(sources, proxyDirs, managementURL)
+}
+
+// credentialLocations describes where a provider looked for credentials, for
+// the warning shown when it found none: files matching pattern in each proxy
+// directory or at the management API, and the native file at nativePath.
+func credentialLocations(sources []CredentialSource, dirs []string, pattern string, management *managementClient, nativePath string) string {
+	var locations []string
+	for _, source := range sources {
+		switch source {
+		case SourceNative:
+			locations = append(locations, nativePath)
+		case SourceManagement:
+			locations = append(locations, pattern+" at "+management.baseURL)
+		default:
+			for _, dir := range dirs {
+				locations = append(locations, filepath.Join(dir, pattern))
+			}
+		}
+	}
+	return strings.Join(locations, " or ")
+}
+
+// String returns the short source name used in rows and JSON: "proxy",
+// "native" or "management".
+func (s CredentialSource) String() string {
+	switch s {
+	case SourceProxy:
+		return "proxy"
+	case SourceNative:
+		return "native"
+	case SourceManagement:
+		return "management"
+	}
+	return "unknown"
+}
+
+// SourceMode selects which credential sources a provider loads.
+type SourceMode string
+
+const (
+	// SourceModeAuto uses the management API when one is configured, else the
+	// provider's own proxy files if there are any, otherwise its native CLI
+	// credentials.
+	SourceModeAuto SourceMode = "auto"
+	// SourceModeProxy only loads CLIProxyAPI credential files.
+	SourceModeProxy SourceMode = "proxy"
+	// SourceModeNative only loads native CLI credentials.
+	SourceModeNative SourceMode = "native"
+	// SourceModeManagement only loads credentials from the management API.
+	SourceModeManagement SourceMode = "management"
+	// SourceModeAll loads CLIProxyAPI credentials (from the management API
+	// when configured) and native ones, dropping native accounts already
+	// present in the proxy.
+	SourceModeAll SourceMode = "all"
+)
+
+// ParseSourceMode parses a --source flag or config value. An empty value
+// selects SourceModeAuto.
+func ParseSourceMode(value string) (SourceMode, error) {
+	mode := SourceMode(strings.ToLower(strings.TrimSpace(value)))
+	switch mode {
+	case "":
+		return SourceModeAuto, nil
+	case SourceModeAuto, SourceModeProxy, SourceModeNative, SourceModeManagement, SourceModeAll:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown credential source %q (expected auto, proxy, native, management or all)", value)
+}
+
+// sources returns the credential sources to load, in order of preference,
+// for a provider whose proxy files in dirs match pattern. management reports
+// whether a management API is configured.
+func (m SourceMode) sources(dirs []string, pattern string, management bool) []CredentialSource {
+	proxy := SourceProxy
+	if management {
+		proxy = SourceManagement
+	}
+	switch m {
+	case SourceModeProxy:
+		return []CredentialSource{SourceProxy}
+	case SourceModeNative:
+		return []CredentialSource{SourceNative}
+	case SourceModeManagement:
+		return []CredentialSource{SourceManagement}
+	case SourceModeAll:
+		return []CredentialSource{proxy, SourceNative}
+	}
+	if management {
+		return []CredentialSource{SourceManagement}
+	}
+	if len(globCredentialFiles(dirs, pattern)) > 0 {
+		return []CredentialSource{SourceProxy}
+	}
+	return []CredentialSource{SourceNative}
+}
+
+// SourceReporter is implemented by providers that can report which
+// credential sources they load under their current options.
+type SourceReporter interface {
+	CredentialSources() []CredentialSource
+}
+
+// setSource records the credential source on rows that do not already carry one.
+func setSource(rows []UsageRow, source CredentialSource) {
+	for i := range rows {
+		if rows[i].Source == "" {
+			rows[i].Source = source.String()
+		}
+	}
+}
@@ -3,99 +3,84 @@ package providers
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
-func TestDetectCredentialSource_ProxyExists(t *testing.T) {
-	tmpDir := t.TempDir()
-	proxyDir := filepath.Join(tmpDir, ".cli-proxy-api")
-	if err := os.MkdirAll(proxyDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-	// Create a claude credential file
-	if err := os.WriteFile(filepath.Join(proxyDir, "claude-test.json"), []byte("{}"), 0644); err != nil {
-		t.Fatal(err)
+func TestCredentialSource_DisplayName_Unknown(t *testing.T) {
+	// Test unknown source value
+	source := CredentialSource(99)
+	if source.DisplayName() != "unknown" {
+		t.Errorf("expected 'unknown', got %q", source.DisplayName())
 	}
+}
 
-	source := DetectCredentialSource(tmpDir)
-	if source != SourceProxy {
-		t.Errorf("expected SourceProxy, got %v", source)
+func TestDescribeSources(t *testing.T) {
+	sources := []CredentialSource{SourceManagement, SourceProxy, SourceNative}
+	got := strings.Join(DescribeSources(sources, []string{"/srv/a", "/srv/b"}, "http://proxy:8317"), "; ")
+	want := "http://proxy:8317; /srv/a, /srv/b; native CLI directories"
+	if got != want {
+		t.Errorf("DescribeSources() = %q, want %q", got, want)
 	}
-	if source.DisplayName() != "~/.cli-proxy-api/" {
-		t.Errorf("expected '~/.cli-proxy-api/', got %q", source.DisplayName())
+	if got := DescribeSources([]CredentialSource{SourceProxy}, nil, ""); got[0] != "~/.cli-proxy-api/" {
+		t.Errorf("expected default proxy dir, got %q", got[0])
 	}
 }
 
-func TestDetectCredentialSource_ProxyEmpty(t *testing.T) {
-	tmpDir := t.TempDir()
-	// Create empty proxy dir
-	proxyDir := filepath.Join(tmpDir, ".cli-proxy-api")
-	if err := os.MkdirAll(proxyDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-
-	source := DetectCredentialSource(tmpDir)
-	if source != SourceNative {
-		t.Errorf("expected SourceNative, got %v", source)
+func TestProxyCredentialDir(t *testing.T) {
+	if got := ProxyCredentialDir("/home/u", ""); got != filepath.Join("/home/u", ".cli-proxy-api") {
+		t.Errorf("unexpected default dir %q", got)
 	}
-	if source.DisplayName() != "native CLI directories" {
-		t.Errorf("expected 'native CLI directories', got %q", source.DisplayName())
+	if got := ProxyCredentialDir("/home/u", "/srv/proxy"); got != "/srv/proxy" {
+		t.Errorf("expected override, got %q", got)
 	}
-}
-
-func TestDetectCredentialSource_ProxyDirMissing(t *testing.T) {
-	tmpDir := t.TempDir()
-	// No proxy dir at all
-
-	source := DetectCredentialSource(tmpDir)
-	if source != SourceNative {
-		t.Errorf("expected SourceNative, got %v", source)
+	if got := ProxyCredentialDir("", ""); got != "" {
+		t.Errorf("expected empty dir for empty home, got %q", got)
 	}
 }
 
-func TestDetectCredentialSource_MixedProviders(t *testing.T) {
-	// Test that ANY proxy credential triggers SourceProxy
+func TestParseSourceMode(t *testing.T) {
 	tests := []struct {
-		name     string
-		filename string
+		input   string
+		want    SourceMode
+		wantErr bool
 	}{
-		{"claude", "claude-test.json"},
-		{"codex", "codex-test.json"},
-		{"gemini", "gemini-test.json"},
+		{"", SourceModeAuto, false},
+		{"auto", SourceModeAuto, false},
+		{" Proxy ", SourceModeProxy, false},
+		{"native", SourceModeNative, false},
+		{"ALL", SourceModeAll, false},
+		{"both", "", true},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tmpDir := t.TempDir()
-			proxyDir := filepath.Join(tmpDir, ".cli-proxy-api")
-			if err := os.MkdirAll(proxyDir, 0755); err != nil {
-				t.Fatal(err)
-			}
-			if err := os.WriteFile(filepath.Join(proxyDir, tt.filename), []byte("{}"), 0644); err != nil {
-				t.Fatal(err)
-			}
-
-			source := DetectCredentialSource(tmpDir)
-			if source != SourceProxy {
-				t.Errorf("expected SourceProxy for %s, got %v", tt.filename, source)
-			}
-		})
+		got, err := ParseSourceMode(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSourceMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSourceMode(%q) = %q, want %q", tt.input, got, tt.want)
+		}
 	}
 }
 
-func TestCredentialSource_DisplayName_Unknown(t *testing.T) {
-	// Test unknown source value
-	source := CredentialSource(99)
-	if source.DisplayName() != "unknown" {
-		t.Errorf("expected 'unknown', got %q", source.DisplayName())
+func TestSourceMode_AutoIsPerProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "codex-a.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
 	}
-}
 
-func TestDetectCredentialSource_EmptyHomeDir(t *testing.T) {
-	// When homeDir is empty (e.g., os.UserHomeDir() fails in CI),
-	// should return SourceNative to avoid scanning current directory
-	source := DetectCredentialSource("")
-	if source != SourceNative {
-		t.Errorf("expected SourceNative for empty homeDir, got %v", source)
+	if got := SourceModeAuto.sources([]string{dir}, "codex-*.json", false); len(got) != 1 || got[0] != SourceProxy {
+		t.Errorf("expected proxy for codex, got %v", got)
+	}
+	if got := SourceModeAuto.sources([]string{dir}, "claude-*.json", false); len(got) != 1 || got[0] != SourceNative {
+		t.Errorf("expected native for claude, got %v", got)
+	}
+	if got := SourceModeAll.sources([]string{dir}, "claude-*.json", false); len(got) != 2 || got[0] != SourceProxy || got[1] != SourceNative {
+		t.Errorf("expected proxy then native for all, got %v", got)
+	}
+	if got := SourceModeNative.sources([]string{dir}, "codex-*.json", false); len(got) != 1 || got[0] != SourceNative {
+		t.Errorf("expected native when forced, got %v", got)
 	}
 }
@@ -3,6 +3,7 @@ package providers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 	"unicode/utf8"
 )
@@ -17,14 +18,115 @@ func UserAgent() string {
 
 // UsageRow represents a single row in the output table
 type UsageRow struct {
-	Provider     string    // e.g., "Claude (user@example.com)", "Codex (user@example.com)", "Gemini (user@example.com)"
-	Label        string    // e.g., "5-hour", "7-day", "gemini-2.5-pro"
-	UsagePercent float64   // 0-100
-	ResetTime    time.Time // When quota resets
-	IsWarning    bool      // If true, this is a warning row
-	WarningMsg   string    // Warning message (only if IsWarning)
-	DebugInfo    string    // Optional debug metadata (only shown with --debug)
-	IsGroup      bool      // If true, this is a group header row (display-only)
+	Provider     string        // e.g., "Claude (user@example.com)", "Codex (user@example.com)", "Gemini (user@example.com)"
+	Label        string        // e.g., "5-hour", "7-day", "gemini-2.5-pro"
+	Plan         string        // Account plan or tier, e.g. "pro", "plus", "free"; empty if unknown
+	Source       string        // Credential source the row came from: "proxy" or "native"; empty if unknown
+	UsagePercent float64       // 0-100
+	ResetTime    time.Time     // When quota resets; zero if unknown
+	Window       time.Duration // Length of the quota window; zero if unknown
+	Blocked      bool          // If true, the provider rejects requests until the window resets
+	IsWarning    bool          // If true, this is a warning row
+	WarningMsg   string        // Warning message (only if IsWarning)
+	DebugInfo    string        // Optional debug metadata (only shown with --debug)
+	IsGroup      bool          // If true, this is a group header row (display-only)
+	Forecast     *Forecast     // Optional burn-rate projection (nil when unknown)
+	Spend        *Spend        // Optional pay-as-you-go spend (e.g. Claude extra usage)
+	Credits      *Credits      // Optional prepaid credit balance (e.g. Codex credits)
+	Quota        *Quota        // Optional limit whose usage is not reported (e.g. Qwen requests)
+}
+
+// HasUsagePercent reports whether UsagePercent is meaningful for the row.
+// Credit and quota rows never carry a percentage, and spend rows only do when
+// billing is enabled with a limit.
+func (r UsageRow) HasUsagePercent() bool {
+	if r.IsWarning || r.IsGroup || r.Credits != nil || r.Quota != nil {
+		return false
+	}
+	return r.Spend == nil || (r.Spend.Enabled && r.Spend.Limit != nil)
+}
+
+// Spend describes pay-as-you-go usage billed beyond the plan quota, in US dollars.
+type Spend struct {
+	Enabled bool     // Whether extra usage billing is turned on
+	Used    *float64 // Amount spent this month; nil if not reported
+	Limit   *float64 // Monthly spending limit; nil if unlimited or not reported
+}
+
+// Quota describes a known limit for a window whose usage the provider does
+// not report.
+type Quota struct {
+	Limit int64  // Amount allowed per window
+	Unit  string // What the limit counts, e.g. "requests"
+}
+
+// Credits describes a prepaid credit balance that can be used once the plan
+// quota is exhausted.
+type Credits struct {
+	HasCredits          bool     // Whether the account has any credits
+	Unlimited           bool     // Whether credits are unlimited
+	Balance             *float64 // Remaining balance; nil if not reported
+	ApproxLocalMessages []int    // Approximate local messages the balance covers, as [low, high]
+	ApproxCloudMessages []int    // Approximate cloud messages the balance covers, as [low, high]
+}
+
+// WindowLabel renders a window length as a label like "5-hour" or "7-day".
+// It returns "" for non-positive durations.
+func WindowLabel(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d-day", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d-hour", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d-minute", d/time.Minute)
+	}
+	return fmt.Sprintf("%d-second", d/time.Second)
+}
+
+// Forecast projects when a usage window will be exhausted at the current burn rate.
+type Forecast struct {
+	RatePerHour float64   // Percentage points consumed per hour
+	ExhaustsAt  time.Time // When usage reaches 100%; zero if not before the window resets
+}
+
+// SplitProviderName splits a display name like "Codex (user@example.com)" into
+// the provider name and account detail. Names without an account suffix are
+// returned unchanged with an empty account.
+func SplitProviderName(provider string) (string, string) {
+	start := strings.Index(provider, " (")
+	if start == -1 || !strings.HasSuffix(provider, ")") {
+		return provider, ""
+	}
+	name := strings.TrimSpace(provider[:start])
+	detail := strings.TrimSuffix(provider[start+2:], ")")
+	if name == "" || detail == "" {
+		return provider, ""
+	}
+	return name, detail
+}
+
+// Options configures a provider. Zero values select the provider defaults.
+type Options struct {
+	// CredentialsDirs replace the CLIProxyAPI credential directory
+	// (~/.cli-proxy-api); files are read from each in order.
+	CredentialsDirs []string
+	// Timeout overrides the per-request HTTP timeout.
+	Timeout time.Duration
+	// RefreshSkew is how long before expiry a token is refreshed proactively;
+	// zero selects DefaultRefreshSkew.
+	RefreshSkew time.Duration
+	// NoRefresh disables all token refreshes, so credential files are never written.
+	NoRefresh bool
+	// Source selects proxy and/or native credentials; empty selects SourceModeAuto.
+	Source SourceMode
+	// ManagementURL is the base URL of a CLIProxyAPI whose management API
+	// supplies credentials instead of the credential directory.
+	ManagementURL string
+	// ManagementKey authenticates management API requests.
+	ManagementKey string
 }
 
 // Provider defines the interface all quota providers must implement
@@ -1,6 +1,9 @@
 package providers
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestTruncateBody(t *testing.T) {
 	tests := []struct {
@@ -34,3 +37,22 @@ func TestTruncateBody(t *testing.T) {
 		})
 	}
 }
+
+func TestWindowLabel(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   string
+	}{
+		{0, ""},
+		{5 * time.Hour, "5-hour"},
+		{7 * 24 * time.Hour, "7-day"},
+		{24 * time.Hour, "1-day"},
+		{90 * time.Minute, "90-minute"},
+		{45 * time.Second, "45-second"},
+	}
+	for _, tt := range tests {
+		if got := WindowLabel(tt.window); got != tt.want {
+			t.Errorf("WindowLabel(%v) = %q, want %q", tt.window, got, tt.want)
+		}
+	}
+}
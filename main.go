@@ -3,55 +3,204 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"math"
 	"os"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/charlieyou/aim/internal/config"
+	"github.com/charlieyou/aim/internal/history"
 	"github.com/charlieyou/aim/internal/output"
 	"github.com/charlieyou/aim/internal/providers"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "history":
+			os.Exit(runHistory(os.Args[2:]))
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "refresh":
+			os.Exit(runRefresh(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		}
+	}
+
 	debug := flag.Bool("debug", false, "Show debug metadata for usage rows")
-	showGeminiOld := flag.Bool("gemini-old", false, "Show Gemini 2.x models (gemini-2*)")
+	showGeminiOld := flag.Bool("gemini-old", false, "Show Gemini 2.x models (gemini-2*) and other hidden models")
+	format := flag.String("format", "", "Output format: table or json (default from config, else table)")
+	color := flag.String("color", "", "Color output: auto, always or never (default from config, else auto)")
+	configPath := flag.String("config", "", "Path to config file (default ~/.config/aim/config.yaml)")
+	watch := flag.Duration("watch", 0, "Redraw the table in place every interval (e.g. 60s)")
+	noHistory := flag.Bool("no-history", false, "Do not record this fetch in the local usage history")
+	providerList := flag.String("providers", "", "Comma-separated providers to query (default: all enabled in config)")
+	noRefresh := flag.Bool("no-refresh", false, "Never refresh tokens or rewrite credential files")
+	source := flag.String("source", "", "Credential source: auto, proxy, native, management or all (default from config, else auto)")
+	var authDirs stringsFlag
+	flag.Var(&authDirs, "auth-dir", "Directory of CLIProxyAPI credential files; repeat for several (default from config, else ~/.cli-proxy-api)")
+	proxyConfig := flag.String("proxy-config", "", "Path to a CLIProxyAPI config.yaml whose auth-dir holds credential files")
 	flag.Parse()
 	providers.SetDebug(*debug)
 
-	// Detect and display credential source
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		credSource := providers.DetectCredentialSource(homeDir)
-		output.PrintCredentialSource(os.Stdout, credSource.DisplayName())
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim: %v\n", err)
+		os.Exit(2)
+	}
+	if *noRefresh {
+		cfg.NoRefresh = true
+	}
+	if err := applySourceMode(cfg, *source); err != nil {
+		fmt.Fprintf(os.Stderr, "aim: %v\n", err)
+		os.Exit(2)
+	}
+	if err := applyAuthDirs(cfg, authDirs, *proxyConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "aim: %v\n", err)
+		os.Exit(2)
+	}
+	selected, err := providers.ParseProviderList(*providerList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim: %v\n", err)
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = cfg.Format
+	}
+	if *color == "" {
+		*color = cfg.Color
+	}
+	if err := applyColorMode(*color); err != nil {
+		fmt.Fprintf(os.Stderr, "aim: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "aim: unknown format %q (expected table or json)\n", *format)
+		os.Exit(2)
+	}
+	if *watch < 0 || (*watch > 0 && *watch < minWatchInterval) {
+		fmt.Fprintf(os.Stderr, "aim: --watch interval must be at least %s\n", minWatchInterval)
+		os.Exit(2)
+	}
+	if *watch > 0 && *format != "table" {
+		fmt.Fprintln(os.Stderr, "aim: --watch only supports the table format")
+		os.Exit(2)
+	}
+
+	list := newProviders(cfg, selected)
+	sourceName := credentialSourceName(cfg, list)
+
+	if *watch > 0 {
+		os.Exit(runWatch(list, watchOptions{
+			interval:         *watch,
+			timeout:          cfg.Timeout,
+			sourceName:       sourceName,
+			hiddenModels:     hiddenModels(cfg, *showGeminiOld),
+			debug:            *debug,
+			noHistory:        *noHistory,
+			historyRetention: cfg.HistoryRetention(),
+		}))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	if *format == "table" && sourceName != "" {
+		output.PrintCredentialSource(os.Stdout, sourceName)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
 	defer cancel()
 
+	var samples []history.Record
+	if !*noHistory {
+		samples = loadForecastSamples(time.Now())
+	}
+
+	allRows := collectRows(ctx, list)
+	applyForecasts(allRows, samples, time.Now())
+	if !*noHistory {
+		recordHistory(allRows, cfg.HistoryRetention())
+	}
+
+	allRows = filterRows(allRows, hiddenModels(cfg, *showGeminiOld))
+
+	// Sort rows
+	sortRows(allRows)
+
+	if *format == "json" {
+		// JSON keeps the raw provider/account split, so render before display grouping.
+		if err := output.RenderJSON(allRows, os.Stdout, sourceName); err != nil {
+			fmt.Fprintf(os.Stderr, "aim: failed to write JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	output.RenderTable(displayRows(allRows), os.Stdout, *debug)
+}
+
+// displayRows applies the table-only grouping transforms to sorted rows.
+func displayRows(rows []providers.UsageRow) []providers.UsageRow {
+	rows = formatGeminiRows(rows)
+	return groupProviderRows(rows)
+}
+
+// namedProvider pairs a constructed provider with its display name, or holds
+// the constructor error when the provider could not be created.
+type namedProvider struct {
+	name     string
+	provider providers.Provider
+	err      error
+}
+
+// newProviders constructs every registered provider that is selected on the
+// command line, or enabled in cfg when there is no selection.
+func newProviders(cfg *config.Config, selected []string) []namedProvider {
+	var list []namedProvider
+	for _, reg := range providers.Registered() {
+		if !providerSelected(cfg, selected, reg.Name) {
+			continue
+		}
+		provider, err := reg.New(cfg.ProviderOptions(reg.Name))
+		list = append(list, namedProvider{name: reg.Name, provider: provider, err: err})
+	}
+	return list
+}
+
+// providerSelected reports whether a provider should run. An explicit
+// --providers selection overrides the config file's enabled settings.
+func providerSelected(cfg *config.Config, selected []string, name string) bool {
+	if len(selected) == 0 {
+		return cfg.ProviderEnabled(name)
+	}
+	for _, s := range selected {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// collectRows fetches usage from all providers concurrently. Constructor and
+// fetch failures are reported as warning rows rather than errors.
+func collectRows(ctx context.Context, list []namedProvider) []providers.UsageRow {
 	var allRows []providers.UsageRow
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	// Create providers, handle constructor errors
-	providerFactories := []struct {
-		name    string
-		factory func() (providers.Provider, error)
-	}{
-		{"Claude", func() (providers.Provider, error) { return providers.NewClaudeProvider() }},
-		{"Codex", func() (providers.Provider, error) { return providers.NewCodexProvider() }},
-		{"Gemini", func() (providers.Provider, error) { return providers.NewGeminiProvider() }},
-	}
-
-	for _, pf := range providerFactories {
-		provider, err := pf.factory()
-		if err != nil {
+	for _, np := range list {
+		if np.err != nil {
 			// Constructor failed - add warning row
 			mu.Lock()
 			allRows = append(allRows, providers.UsageRow{
-				Provider:   pf.name,
+				Provider:   np.name,
 				IsWarning:  true,
-				WarningMsg: err.Error(),
+				WarningMsg: np.err.Error(),
 			})
 			mu.Unlock()
 			continue
@@ -73,66 +222,34 @@ func main() {
 				allRows = append(allRows, rows...)
 			}
 			mu.Unlock()
-		}(provider, pf.name)
+		}(np.provider, np.name)
 	}
 
 	wg.Wait()
-
-	allRows = filterRows(allRows, *showGeminiOld)
-
-	// Sort rows
-	sortRows(allRows)
-
-	allRows = formatGeminiRows(allRows)
-	allRows = groupProviderRows(allRows)
-
-	output.RenderTable(allRows, os.Stdout, *debug)
+	return allRows
 }
 
 // sortRows sorts usage rows by:
-// 1. Provider order: Claude, Codex, Gemini (based on prefix)
+// 1. Provider order, as registered (based on prefix)
 // 2. Warnings last within each provider group
 // 3. Full provider name (for multi-account providers like Codex)
 // 4. Alphabetical by Label within each provider
 func sortRows(rows []providers.UsageRow) {
-	providerOrder := map[string]int{
-		"Claude": 0,
-		"Codex":  1,
-		"Gemini": 2,
-	}
-
-	// providerPrefixes defines the canonical prefixes in deterministic order.
-	// Longer prefixes are checked first to handle potential overlaps correctly.
-	providerPrefixes := []string{"Claude", "Codex", "Gemini"}
-
-	// getProviderPrefix extracts the base provider name from Provider field
-	// e.g., "Codex (user@example.com)" -> "Codex"
-	getProviderPrefix := func(provider string) string {
-		for _, prefix := range providerPrefixes {
-			if len(provider) >= len(prefix) && provider[:len(prefix)] == prefix {
-				return prefix
-			}
-		}
-		return provider
-	}
-
-	// getOrder returns the sort order for a provider prefix.
+	// getOrder returns the registry sort order for a row's provider, e.g.
+	// "Codex (user@example.com)" -> Codex's order.
 	// Unknown providers get a high value to sort after known ones.
-	getOrder := func(prefix string) int {
-		if order, ok := providerOrder[prefix]; ok {
-			return order
+	getOrder := func(provider string) int {
+		name, _ := providers.SplitProviderName(provider)
+		if reg, ok := providers.Lookup(name); ok {
+			return reg.Order
 		}
-		return len(providerOrder) // Unknown providers sort last
+		return math.MaxInt
 	}
 
 	sort.SliceStable(rows, func(i, j int) bool {
-		// Get provider prefixes
-		prefixI := getProviderPrefix(rows[i].Provider)
-		prefixJ := getProviderPrefix(rows[j].Provider)
-
 		// Primary: Provider order
-		orderI := getOrder(prefixI)
-		orderJ := getOrder(prefixJ)
+		orderI := getOrder(rows[i].Provider)
+		orderJ := getOrder(rows[j].Provider)
 		if orderI != orderJ {
 			return orderI < orderJ
 		}
@@ -152,8 +269,10 @@ func sortRows(rows []providers.UsageRow) {
 	})
 }
 
-func filterRows(rows []providers.UsageRow, showGeminiOld bool) []providers.UsageRow {
-	if showGeminiOld {
+// filterRows drops usage rows whose label starts with any of the hidden model
+// prefixes (case-insensitive). Warning rows are always kept.
+func filterRows(rows []providers.UsageRow, hidden []string) []providers.UsageRow {
+	if len(hidden) == 0 {
 		return rows
 	}
 
@@ -163,7 +282,7 @@ func filterRows(rows []providers.UsageRow, showGeminiOld bool) []providers.Usage
 			filtered = append(filtered, row)
 			continue
 		}
-		if strings.HasPrefix(row.Provider, "Gemini") && isGemini2xModel(row.Label) {
+		if isHiddenModel(row.Label, hidden) {
 			continue
 		}
 		filtered = append(filtered, row)
@@ -171,22 +290,27 @@ func filterRows(rows []providers.UsageRow, showGeminiOld bool) []providers.Usage
 	return filtered
 }
 
-func isGemini2xModel(label string) bool {
-	return strings.HasPrefix(strings.ToLower(label), "gemini-2")
+func isHiddenModel(label string, hidden []string) bool {
+	lower := strings.ToLower(label)
+	for _, prefix := range hidden {
+		if prefix != "" && strings.HasPrefix(lower, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
 }
 
 func formatGeminiRows(rows []providers.UsageRow) []providers.UsageRow {
 	const (
-		geminiPrefix = "Gemini"
-		windowLabel  = "24-hour"
-		modelIndent  = "  "
+		windowLabel = "24-hour"
+		modelIndent = "  "
 	)
 
 	formatted := make([]providers.UsageRow, 0, len(rows))
 	seenHeader := make(map[string]bool)
 
 	for _, row := range rows {
-		if !strings.HasPrefix(row.Provider, geminiPrefix) {
+		if name, _ := providers.SplitProviderName(row.Provider); name != providers.GeminiName {
 			formatted = append(formatted, row)
 			continue
 		}
@@ -202,12 +326,16 @@ func formatGeminiRows(rows []providers.UsageRow) []providers.UsageRow {
 			if !seenHeader[row.Provider] {
 				formatted = append(formatted, providers.UsageRow{
 					Provider: row.Provider,
+					Plan:     row.Plan,
+					Source:   row.Source,
 					IsGroup:  true,
 				})
 				seenHeader[row.Provider] = true
 			}
 
 			row.Provider = modelIndent + row.Label
+			row.Plan = ""
+			row.Source = ""
 			row.Label = windowLabel
 			formatted = append(formatted, row)
 			continue
@@ -246,6 +374,8 @@ func groupProviderRows(rows []providers.UsageRow) []providers.UsageRow {
 
 		if groupedProviders[row.Provider] {
 			row.Provider = ""
+			row.Plan = ""
+			row.Source = ""
 			formatted = append(formatted, row)
 			continue
 		}
@@ -258,12 +388,16 @@ func groupProviderRows(rows []providers.UsageRow) []providers.UsageRow {
 		if !seenHeader[row.Provider] {
 			formatted = append(formatted, providers.UsageRow{
 				Provider: row.Provider,
+				Plan:     row.Plan,
+				Source:   row.Source,
 				IsGroup:  true,
 			})
 			seenHeader[row.Provider] = true
 		}
 
 		row.Provider = ""
+		row.Plan = ""
+		row.Source = ""
 		formatted = append(formatted, row)
 	}
 
@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/charlieyou/aim/internal/config"
+	"github.com/charlieyou/aim/internal/history"
 	"github.com/charlieyou/aim/internal/providers"
 )
 
@@ -14,7 +24,7 @@ func TestFilterRows_ShowOld(t *testing.T) {
 		{Provider: "Codex", Label: "5-hour"},
 	}
 
-	filtered := filterRows(rows, true)
+	filtered := filterRows(rows, nil)
 	if !reflect.DeepEqual(filtered, rows) {
 		t.Fatalf("expected rows unchanged when showing old models")
 	}
@@ -29,7 +39,7 @@ func TestFilterRows_HideGemini2xByDefault(t *testing.T) {
 		{Provider: "Gemini (a)", Label: "Gemini-2.0-flash"},
 	}
 
-	filtered := filterRows(rows, false)
+	filtered := filterRows(rows, config.Default().HiddenModels)
 	if len(filtered) != 3 {
 		t.Fatalf("expected 3 rows after filtering, got %d", len(filtered))
 	}
@@ -112,3 +122,318 @@ func TestGroupProviderRows_AddsHeaderAndBlanksProvider(t *testing.T) {
 		t.Fatalf("expected non-grouped provider unchanged, got %+v", grouped[3])
 	}
 }
+
+type stubProvider struct {
+	name string
+	rows []providers.UsageRow
+	err  error
+}
+
+func (s stubProvider) Name() string { return s.name }
+
+func (s stubProvider) FetchUsage(ctx context.Context) ([]providers.UsageRow, error) {
+	return s.rows, s.err
+}
+
+func TestCollectRows_WarningsForFailures(t *testing.T) {
+	list := []namedProvider{
+		{name: "Claude", err: errors.New("no home")},
+		{name: "Codex", provider: stubProvider{name: "Codex", err: errors.New("boom")}},
+		{name: "Gemini", provider: stubProvider{name: "Gemini", rows: []providers.UsageRow{
+			{Provider: "Gemini (a)", Label: "gemini-3.0-pro", UsagePercent: 5},
+		}}},
+	}
+
+	rows := collectRows(context.Background(), list)
+	sortRows(rows)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	if !rows[0].IsWarning || rows[0].Provider != "Claude" || rows[0].WarningMsg != "no home" {
+		t.Errorf("expected Claude constructor warning, got %+v", rows[0])
+	}
+	if !rows[1].IsWarning || rows[1].Provider != "Codex" || rows[1].WarningMsg != "boom" {
+		t.Errorf("expected Codex fetch warning, got %+v", rows[1])
+	}
+	if rows[2].IsWarning || rows[2].Label != "gemini-3.0-pro" {
+		t.Errorf("expected Gemini usage row, got %+v", rows[2])
+	}
+}
+
+// sourceStub is a provider reporting fixed credential sources.
+type sourceStub struct {
+	stubProvider
+	sources []providers.CredentialSource
+}
+
+func (s sourceStub) CredentialSources() []providers.CredentialSource { return s.sources }
+
+func TestCredentialSourceName(t *testing.T) {
+	proxy := []providers.CredentialSource{providers.SourceProxy}
+	native := []providers.CredentialSource{providers.SourceNative}
+	cfg := config.Default()
+
+	same := []namedProvider{
+		{name: "Claude", provider: sourceStub{sources: native}},
+		{name: "Codex", provider: sourceStub{sources: native}},
+	}
+	if got := credentialSourceName(cfg, same); got != "native CLI directories" {
+		t.Errorf("shared source = %q", got)
+	}
+
+	mixed := []namedProvider{
+		{name: "Claude", provider: sourceStub{sources: native}},
+		{name: "Codex", provider: sourceStub{sources: proxy}},
+		{name: "Gemini", err: errors.New("no home")},
+	}
+	want := "Claude: native CLI directories; Codex: ~/.cli-proxy-api/"
+	if got := credentialSourceName(cfg, mixed); got != want {
+		t.Errorf("mixed sources = %q, want %q", got, want)
+	}
+
+	cfg.CredentialsDir = "/srv/proxy"
+	all := []namedProvider{
+		{name: "Codex", provider: sourceStub{sources: []providers.CredentialSource{providers.SourceProxy, providers.SourceNative}}},
+	}
+	if got := credentialSourceName(cfg, all); got != "/srv/proxy + native CLI directories" {
+		t.Errorf("custom dir with all sources = %q", got)
+	}
+
+	cfg.AuthDirs = []string{"/srv/team"}
+	if got := credentialSourceName(cfg, all); got != "/srv/proxy, /srv/team + native CLI directories" {
+		t.Errorf("several dirs with all sources = %q", got)
+	}
+}
+
+func TestApplySourceMode_OverridesProviderSettings(t *testing.T) {
+	cfg := config.Default()
+	cfg.Providers = map[string]config.ProviderConfig{"codex": {CredentialSource: "proxy"}}
+
+	if err := applySourceMode(cfg, "native"); err != nil {
+		t.Fatalf("applySourceMode() error = %v", err)
+	}
+	if got := cfg.ProviderOptions("Codex").Source; got != providers.SourceModeNative {
+		t.Errorf("Codex source = %q, want native", got)
+	}
+	if err := applySourceMode(cfg, "both"); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
+
+func TestApplyAuthDirs_ReplacesConfiguredDirs(t *testing.T) {
+	proxyDir := t.TempDir()
+	proxyConfig := filepath.Join(proxyDir, "config.yaml")
+	if err := os.WriteFile(proxyConfig, []byte("auth-dir: auths\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.CredentialsDir = "/srv/proxy"
+	cfg.AuthDirs = []string{"/srv/other"}
+	cfg.Providers = map[string]config.ProviderConfig{"codex": {CredentialsDir: "/srv/codex"}}
+
+	if err := applyAuthDirs(cfg, []string{"/a", "/b"}, proxyConfig); err != nil {
+		t.Fatalf("applyAuthDirs() error = %v", err)
+	}
+	want := []string{"/a", "/b", filepath.Join(proxyDir, "auths")}
+	if got := cfg.ProviderOptions("Claude").CredentialsDirs; !reflect.DeepEqual(got, want) {
+		t.Errorf("Claude dirs = %q, want %q", got, want)
+	}
+	if got := cfg.ProviderOptions("Codex").CredentialsDirs; !reflect.DeepEqual(got, []string{"/srv/codex"}) {
+		t.Errorf("expected per-provider dir kept, got %q", got)
+	}
+
+	unchanged := config.Default()
+	unchanged.CredentialsDir = "/srv/proxy"
+	if err := applyAuthDirs(unchanged, nil, ""); err != nil || unchanged.CredentialsDir != "/srv/proxy" {
+		t.Errorf("expected config kept without flags, got %q (err %v)", unchanged.CredentialsDir, err)
+	}
+	if err := applyAuthDirs(config.Default(), nil, filepath.Join(proxyDir, "missing.yaml")); err == nil {
+		t.Error("expected error for a missing proxy config")
+	}
+}
+
+func TestMetricsHandler_ServesCachedRows(t *testing.T) {
+	cache := &usageCache{}
+	cache.store([]providers.UsageRow{
+		{Provider: "Codex (a)", Label: "5-hour", Source: "proxy", UsagePercent: 90},
+	}, time.Unix(1700000000, 0))
+
+	rec := httptest.NewRecorder()
+	newMetricsHandler(cache).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `aim_usage_percent{provider="Codex",account="a",source="proxy",window="5-hour"} 90`) {
+		t.Errorf("missing usage gauge in body:\n%s", body)
+	}
+	if !strings.Contains(body, "aim_last_update_timestamp_seconds 1700000000") {
+		t.Errorf("missing last update gauge in body:\n%s", body)
+	}
+}
+
+func TestEvaluateCheck_States(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  []providers.UsageRow
+		state int
+	}{
+		{
+			name:  "ok",
+			rows:  []providers.UsageRow{{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 20}},
+			state: checkOK,
+		},
+		{
+			name:  "warning",
+			rows:  []providers.UsageRow{{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 75}},
+			state: checkWarning,
+		},
+		{
+			name: "critical beats warning and unknown",
+			rows: []providers.UsageRow{
+				{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 75},
+				{Provider: "Claude (b)", Label: "7-day", UsagePercent: 95},
+				{Provider: "Gemini", IsWarning: true, WarningMsg: "no creds"},
+			},
+			state: checkCritical,
+		},
+		{
+			name:  "blocked below thresholds is critical",
+			rows:  []providers.UsageRow{{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 40, Blocked: true}},
+			state: checkCritical,
+		},
+		{
+			name: "provider warning is unknown",
+			rows: []providers.UsageRow{
+				{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 10},
+				{Provider: "Claude", IsWarning: true, WarningMsg: "authentication failed"},
+			},
+			state: checkUnknown,
+		},
+		{
+			name:  "no rows is unknown",
+			rows:  nil,
+			state: checkUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluateCheck(tt.rows, 70, 90)
+			if result.state != tt.state {
+				t.Errorf("state = %d, want %d (summary %q)", result.state, tt.state, result.summary)
+			}
+		})
+	}
+}
+
+func TestWriteCheckResult_Perfdata(t *testing.T) {
+	rows := []providers.UsageRow{
+		{Provider: "Codex (a)", Label: "5-hour", UsagePercent: 95.5},
+		{Provider: "Claude", IsWarning: true, WarningMsg: "boom"},
+	}
+
+	var buf strings.Builder
+	writeCheckResult(&buf, evaluateCheck(rows, 70, 90))
+	got := buf.String()
+	want := "AIM CRITICAL - Codex (a) 5-hour 96% (1 provider warnings) | 'Codex (a) 5-hour'=95.5%;70;90;0;100\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestWriteCheckResult_EscapesPipeInSummary(t *testing.T) {
+	rows := []providers.UsageRow{
+		{Provider: "Claude", IsWarning: true, WarningMsg: "API error: status 500: a|b"},
+	}
+
+	var buf strings.Builder
+	writeCheckResult(&buf, evaluateCheck(rows, 70, 90))
+	got := buf.String()
+	if want := "AIM UNKNOWN - Claude: API error: status 500: a/b\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPruneSamples_KeepsForecastWindow(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	samples := []history.Record{
+		{Timestamp: now.Add(-2 * history.ForecastLookback), Window: "too old"},
+		{Timestamp: now.Add(-history.ForecastLookback - time.Second), Window: "just outside"},
+		{Timestamp: now.Add(-history.ForecastLookback), Window: "at cutoff"},
+		{Timestamp: now.Add(-time.Minute), Window: "recent"},
+	}
+
+	kept := pruneSamples(samples, now)
+	var windows []string
+	for _, rec := range kept {
+		windows = append(windows, rec.Window)
+	}
+	if want := []string{"at cutoff", "recent"}; !reflect.DeepEqual(windows, want) {
+		t.Errorf("pruneSamples() kept %q, want %q", windows, want)
+	}
+}
+
+// countingProvider returns fixed rows and calls onFetch with the number of
+// fetches so far.
+type countingProvider struct {
+	rows    []providers.UsageRow
+	calls   atomic.Int32
+	onFetch func(calls int32)
+}
+
+func (p *countingProvider) Name() string { return "Codex" }
+
+func (p *countingProvider) FetchUsage(ctx context.Context) ([]providers.UsageRow, error) {
+	p.onFetch(p.calls.Add(1))
+	return p.rows, nil
+}
+
+func TestWatchLoop_RefetchesAndRedraws(t *testing.T) {
+	defer func(interval time.Duration) { watchRedrawInterval = interval }(watchRedrawInterval)
+	watchRedrawInterval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	provider := &countingProvider{
+		rows: []providers.UsageRow{{Provider: "Codex (a@example.com)", Label: "5h", UsagePercent: 40}},
+		// Stop during the third fetch, after the second has been drawn.
+		onFetch: func(calls int32) {
+			if calls == 3 {
+				cancel()
+			}
+		},
+	}
+	list := []namedProvider{{name: "Codex", provider: provider}}
+
+	var buf strings.Builder
+	done := make(chan int, 1)
+	go func() {
+		done <- watchLoop(ctx, &buf, list, watchOptions{
+			interval:  10 * time.Millisecond,
+			timeout:   time.Second,
+			noHistory: true,
+		})
+	}()
+
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Errorf("watchLoop() = %d, want 0", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchLoop did not stop after its context was canceled")
+	}
+
+	out := buf.String()
+	if got := strings.Count(out, "next refresh in"); got != 2 {
+		t.Errorf("expected the initial draw and one redraw, got %d\n%s", got, out)
+	}
+	if strings.Count(out, "a@example.com") != 2 {
+		t.Errorf("expected the account row in both draws\n%s", out)
+	}
+	if strings.Contains(out, "\x1b[2J") {
+		t.Errorf("redirected output should not clear the screen\n%s", out)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/charlieyou/aim/internal/output"
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// runRefresh implements `aim refresh`: it refreshes proxy tokens that are
+// expired or about to expire without fetching usage, so idle accounts stay
+// usable. It exits non-zero when any account fails.
+func runRefresh(args []string) int {
+	fs := flag.NewFlagSet("refresh", flag.ContinueOnError)
+	skew := fs.Duration("skew", 0, "Refresh tokens expiring within this long (default from config, else 5m)")
+	force := fs.Bool("force", false, "Refresh every proxy token, even ones that are not due")
+	debug := fs.Bool("debug", false, "Log provider debug output")
+	configPath := fs.String("config", "", "Path to config file (default ~/.config/aim/config.yaml)")
+	providerList := fs.String("providers", "", "Comma-separated providers to refresh (default: all enabled in config)")
+	source := fs.String("source", "", "Credential source: auto, proxy, native, management or all (default from config, else auto)")
+	var authDirs stringsFlag
+	fs.Var(&authDirs, "auth-dir", "Directory of CLIProxyAPI credential files; repeat for several (default from config, else ~/.cli-proxy-api)")
+	proxyConfig := fs.String("proxy-config", "", "Path to a CLIProxyAPI config.yaml whose auth-dir holds credential files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *skew < 0 {
+		fmt.Fprintln(os.Stderr, "aim refresh: --skew must not be negative")
+		return 2
+	}
+	providers.SetDebug(*debug)
+	selected, err := providers.ParseProviderList(*providerList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim refresh: %v\n", err)
+		return 2
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim refresh: %v\n", err)
+		return 2
+	}
+	if err := applySourceMode(cfg, *source); err != nil {
+		fmt.Fprintf(os.Stderr, "aim refresh: %v\n", err)
+		return 2
+	}
+	if err := applyAuthDirs(cfg, authDirs, *proxyConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "aim refresh: %v\n", err)
+		return 2
+	}
+	if cfg.NoRefresh {
+		fmt.Fprintln(os.Stderr, "aim refresh: token refresh is disabled by no_refresh in the config file")
+		return 2
+	}
+	if *skew > 0 {
+		cfg.RefreshSkew = *skew
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	results := refreshCredentials(ctx, newProviders(cfg, selected), *force)
+	output.RenderRefreshResults(results, os.Stdout)
+	for _, result := range results {
+		if result.Status == providers.RefreshFailed {
+			return 1
+		}
+	}
+	return 0
+}
+
+// refreshCredentials runs a refresh for every provider that supports it, in
+// provider order. Providers without credentials are reported as skipped.
+func refreshCredentials(ctx context.Context, list []namedProvider, force bool) []providers.RefreshResult {
+	var results []providers.RefreshResult
+	for _, np := range list {
+		if np.err != nil {
+			results = append(results, providers.RefreshResult{
+				Provider: np.name,
+				Status:   providers.RefreshFailed,
+				Message:  np.err.Error(),
+			})
+			continue
+		}
+		refresher, ok := np.provider.(providers.Refresher)
+		if !ok {
+			continue
+		}
+		providerResults := refresher.RefreshCredentials(ctx, force)
+		if len(providerResults) == 0 {
+			providerResults = []providers.RefreshResult{{
+				Provider: np.name,
+				Status:   providers.RefreshSkipped,
+				Message:  "no credentials found",
+			}}
+		}
+		results = append(results, providerResults...)
+	}
+	return results
+}
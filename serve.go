@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charlieyou/aim/internal/output"
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// usageCache holds the most recent set of usage rows for the metrics handler.
+type usageCache struct {
+	mu        sync.RWMutex
+	rows      []providers.UsageRow
+	updatedAt time.Time
+}
+
+func (c *usageCache) store(rows []providers.UsageRow, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rows = rows
+	c.updatedAt = now
+}
+
+func (c *usageCache) load() ([]providers.UsageRow, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rows, c.updatedAt
+}
+
+// runServe implements `aim serve`: it periodically fetches usage from every
+// provider and exposes the latest values as Prometheus gauges on /metrics.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", ":9464", "Address to listen on for /metrics")
+	interval := fs.Duration("interval", 60*time.Second, "How often to refresh usage from providers")
+	debug := fs.Bool("debug", false, "Log provider debug output")
+	showGeminiOld := fs.Bool("gemini-old", false, "Include Gemini 2.x models (gemini-2*) and other hidden models")
+	configPath := fs.String("config", "", "Path to config file (default ~/.config/aim/config.yaml)")
+	noHistory := fs.Bool("no-history", false, "Do not record fetches in the local usage history")
+	providerList := fs.String("providers", "", "Comma-separated providers to query (default: all enabled in config)")
+	noRefresh := fs.Bool("no-refresh", false, "Never refresh tokens or rewrite credential files")
+	source := fs.String("source", "", "Credential source: auto, proxy, native, management or all (default from config, else auto)")
+	var authDirs stringsFlag
+	fs.Var(&authDirs, "auth-dir", "Directory of CLIProxyAPI credential files; repeat for several (default from config, else ~/.cli-proxy-api)")
+	proxyConfig := fs.String("proxy-config", "", "Path to a CLIProxyAPI config.yaml whose auth-dir holds credential files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "aim serve: --interval must be positive")
+		return 2
+	}
+	providers.SetDebug(*debug)
+	selected, err := providers.ParseProviderList(*providerList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim serve: %v\n", err)
+		return 2
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aim serve: %v\n", err)
+		return 2
+	}
+	if *noRefresh {
+		cfg.NoRefresh = true
+	}
+	if err := applySourceMode(cfg, *source); err != nil {
+		fmt.Fprintf(os.Stderr, "aim serve: %v\n", err)
+		return 2
+	}
+	if err := applyAuthDirs(cfg, authDirs, *proxyConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "aim serve: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cache := &usageCache{}
+	list := newProviders(cfg, selected)
+	refresh := func() {
+		fetchCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+		rows := collectRows(fetchCtx, list)
+		if !*noHistory {
+			recordHistory(rows, cfg.HistoryRetention())
+		}
+		rows = filterRows(rows, hiddenModels(cfg, *showGeminiOld))
+		sortRows(rows)
+		cache.store(rows, time.Now())
+	}
+
+	// Populate the cache before accepting scrapes so the first scrape is useful.
+	refresh()
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           newMetricsHandler(cache),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("aim serve: listening on %s (refresh every %s)", *listen, *interval)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "aim serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func newMetricsHandler(cache *usageCache) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		rows, updatedAt := cache.load()
+
+		var buf bytes.Buffer
+		if err := output.RenderPrometheus(rows, &buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !updatedAt.IsZero() {
+			fmt.Fprintf(&buf, "# HELP aim_last_update_timestamp_seconds Unix timestamp of the last usage refresh.\n")
+			fmt.Fprintf(&buf, "# TYPE aim_last_update_timestamp_seconds gauge\n")
+			fmt.Fprintf(&buf, "aim_last_update_timestamp_seconds %d\n", updatedAt.Unix())
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	})
+	return mux
+}
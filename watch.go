@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/charlieyou/aim/internal/history"
+	"github.com/charlieyou/aim/internal/output"
+	"github.com/charlieyou/aim/internal/providers"
+)
+
+// minWatchInterval keeps watch mode from hammering provider APIs.
+const minWatchInterval = 10 * time.Second
+
+// watchRedrawInterval is how often watch mode redraws the table and checks
+// whether the next fetch is due.
+var watchRedrawInterval = time.Second
+
+type watchOptions struct {
+	interval         time.Duration
+	timeout          time.Duration
+	sourceName       string
+	hiddenModels     []string
+	debug            bool
+	noHistory        bool
+	historyRetention time.Duration
+}
+
+// runWatch implements `aim --watch`: providers are constructed once and
+// usage is fetched every interval. On a terminal the table is redrawn in place
+// every second so the countdown and relative reset times stay current; when
+// output is redirected the table is only printed after each fetch.
+func runWatch(list []namedProvider, opts watchOptions) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return watchLoop(ctx, os.Stdout, list, opts)
+}
+
+// watchLoop fetches and draws to w until ctx is done.
+func watchLoop(ctx context.Context, w io.Writer, list []namedProvider, opts watchOptions) int {
+	interactive := output.IsTerminal(w)
+
+	// Samples from earlier fetches in this session serve as burn-rate
+	// baselines, so projections work even with --no-history.
+	var samples []history.Record
+	if !opts.noHistory {
+		samples = loadForecastSamples(time.Now())
+	}
+
+	var rows []providers.UsageRow
+	var updatedAt time.Time
+	fetch := func() {
+		fetchCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+		fetched := collectRows(fetchCtx, list)
+		now := time.Now()
+		applyForecasts(fetched, samples, now)
+		samples = append(pruneSamples(samples, now), history.RecordsFromRows(fetched, now)...)
+		if !opts.noHistory {
+			recordHistory(fetched, opts.historyRetention)
+		}
+		fetched = filterRows(fetched, opts.hiddenModels)
+		sortRows(fetched)
+		rows = displayRows(fetched)
+		updatedAt = time.Now()
+	}
+	draw := func() {
+		if interactive {
+			output.ClearScreen(w)
+		}
+		if opts.sourceName != "" {
+			output.PrintCredentialSource(w, opts.sourceName)
+		}
+		output.RenderTable(rows, w, opts.debug)
+		output.PrintWatchStatus(w, updatedAt, time.Until(updatedAt.Add(opts.interval)))
+	}
+
+	fetch()
+	draw()
+
+	redraw := time.NewTicker(watchRedrawInterval)
+	defer redraw.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-redraw.C:
+			if time.Since(updatedAt) >= opts.interval {
+				fetch()
+				if ctx.Err() != nil {
+					return 0
+				}
+				draw()
+				continue
+			}
+			if interactive {
+				draw()
+			}
+		}
+	}
+}
+
+// pruneSamples drops samples too old to serve as forecast baselines.
+func pruneSamples(samples []history.Record, now time.Time) []history.Record {
+	cutoff := now.Add(-history.ForecastLookback)
+	kept := samples[:0]
+	for _, rec := range samples {
+		if !rec.Timestamp.Before(cutoff) {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}